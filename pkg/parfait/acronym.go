@@ -0,0 +1,39 @@
+package parfait
+
+import "maps"
+
+// builtinAcronyms are spoken forms of common technical acronyms per primary language
+var builtinAcronyms = map[string]map[string]string{
+	"en": {
+		"API": "A P I", "CLI": "C L I", "CPU": "C P U", "GPU": "G P U", "GUI": "gooey",
+		"HTTP": "H T T P", "HTTPS": "H T T P S", "JSON": "jason", "LLM": "L L M",
+		"OAuth": "oh auth", "SaaS": "sass", "SQL": "sequel", "UI": "U I", "URL": "U R L",
+		"UX": "U X", "YAML": "yamel", "CI/CD": "C I C D", "AWS": "A W S", "GCP": "G C P",
+		"k8s": "kubernetes", "i18n": "internationalization",
+	},
+	"ja": {
+		"API": "エーピーアイ", "CLI": "シーエルアイ", "CPU": "シーピーユー", "GPU": "ジーピーユー",
+		"GUI": "ジーユーアイ", "HTTP": "エイチティーティーピー", "HTTPS": "エイチティーティーピーエス",
+		"JSON": "ジェイソン", "LLM": "エルエルエム", "OAuth": "オーオース", "SaaS": "サース",
+		"SQL": "エスキューエル", "UI": "ユーアイ", "URL": "ユーアールエル", "UX": "ユーエックス",
+		"YAML": "ヤムル", "CI/CD": "シーアイシーディー", "AWS": "エーダブリューエス", "GCP": "ジーシーピー",
+		"k8s": "クバネティス", "i18n": "アイエイティーエヌ",
+	},
+}
+
+// loadAcronyms builds the acronym table for lang: the built-in defaults (when builtin is set)
+// overridden by the project table at path, which uses the lexicon file format
+func loadAcronyms(path, lang string, builtin bool) (*lexicon, error) {
+	table := make(map[string]string)
+	if builtin {
+		maps.Copy(table, builtinAcronyms[baseLanguage(lang)])
+	}
+	if path != "" {
+		project, err := readLexiconFile(path, lang)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(table, project)
+	}
+	return newLexicon(table), nil
+}
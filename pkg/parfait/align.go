@@ -0,0 +1,188 @@
+package parfait
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// timedWord is a transcribed word with its position in the slide's audio
+type timedWord struct {
+	Text       string
+	Start, End time.Duration
+}
+
+// alignNarration transcribes every slide's WAV file with word timestamps for aligning the
+// captions. Slides that fail are warned about and keep proportional timing.
+func alignNarration(ctx context.Context, notes []SlideNote, outputDir string, opts ttsOptions) map[int][]timedWord {
+	words := make(map[int][]timedWord, len(notes))
+	for _, note := range notes {
+		if note.Directives.Silence > 0 {
+			continue
+		}
+		path := slideOutputPath(outputDir, note.SlideNumber, opts)
+		if !statOK(path) {
+			continue
+		}
+		fmt.Printf("[Align] Transcribing slide %03d\n", note.SlideNumber)
+		w, err := transcribeWords(ctx, path, opts.Align, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: slide %03d: alignment failed, using proportional timing: %v\n", note.SlideNumber, redactErr(err))
+			continue
+		}
+		words[note.SlideNumber] = w
+	}
+	return words
+}
+
+// transcribeWords transcribes a slide into words with their start and end times
+func transcribeWords(ctx context.Context, path string, t transcriber, opts ttsOptions) ([]timedWord, error) {
+	if t.Backend == qaOpenAI {
+		body, err := transcribeOpenAI(ctx, path, t, "verbose_json", opts)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Words []struct {
+				Word       string
+				Start, End float64
+			}
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("unexpected transcription response: %v", err)
+		}
+		words := make([]timedWord, 0, len(resp.Words))
+		for _, w := range resp.Words {
+			words = append(words, timedWord{Text: w.Word, Start: secondsDuration(w.Start), End: secondsDuration(w.End)})
+		}
+		return words, nil
+	}
+
+	// One word per segment (-ml 1 -sow), written as JSON to <base>.json
+	dir, err := os.MkdirTemp("", "parfait-align-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, "words")
+	if _, err := transcribeWhisperCpp(ctx, path, t, []string{"-ml", "1", "-sow", "-oj", "-of", base}, opts); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(base + ".json")
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Transcription []struct {
+			Offsets struct{ From, To int64 }
+			Text    string
+		}
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("unexpected whisper.cpp output: %v", err)
+	}
+	words := make([]timedWord, 0, len(out.Transcription))
+	for _, s := range out.Transcription {
+		if text := strings.TrimSpace(s.Text); text != "" {
+			words = append(words, timedWord{
+				Text:  text,
+				Start: time.Duration(s.Offsets.From) * time.Millisecond,
+				End:   time.Duration(s.Offsets.To) * time.Millisecond,
+			})
+		}
+	}
+	return words, nil
+}
+
+// alignCues retimes one slide's cues to the words heard in its audio. The spoken text of
+// each cue (texts, as from cueTexts) and the transcript are matched token by token along
+// their edit distance; a cue runs from its first to its last matched token. Cues without a
+// match keep their proportional time, moved so that the cues stay in order. offset is
+// where the slide starts.
+func alignCues(cues []subtitleCue, texts []cueText, words []timedWord, offset time.Duration, lang string) {
+	var want []string
+	var cueOf []int
+	for i, text := range texts {
+		for _, tok := range qaTokens(text.spoken, lang) {
+			want = append(want, tok)
+			cueOf = append(cueOf, i)
+		}
+	}
+	var got []string
+	var wordOf []int
+	for i, w := range words {
+		for _, tok := range qaTokens(w.Text, lang) {
+			got = append(got, tok)
+			wordOf = append(wordOf, i)
+		}
+	}
+	if len(want) == 0 || len(got) == 0 {
+		return
+	}
+
+	type span struct {
+		start, end time.Duration
+		ok         bool
+	}
+	spans := make([]span, len(cues))
+	for i, j := range matchTokens(want, got) {
+		w := words[wordOf[j]]
+		s := &spans[cueOf[i]]
+		if !s.ok {
+			*s = span{start: w.Start, end: w.End, ok: true}
+		}
+		s.start, s.end = min(s.start, w.Start), max(s.end, w.End)
+	}
+	var prevEnd time.Duration
+	for i := range cues {
+		if s := spans[i]; s.ok {
+			cues[i].Start, cues[i].End = offset+s.start, offset+s.end
+		}
+		cues[i].Start = max(cues[i].Start, prevEnd)
+		cues[i].End = max(cues[i].End, cues[i].Start)
+		prevEnd = cues[i].End
+	}
+}
+
+// matchTokens aligns two token sequences by Levenshtein distance and maps each index of a
+// to the index of b it matches exactly; substituted, inserted and dropped tokens are left out
+func matchTokens(a, b []string) map[int]int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+		}
+	}
+
+	matches := make(map[int]int)
+	i, j := len(a), len(b)
+	for i > 0 && j > 0 {
+		switch {
+		case a[i-1] == b[j-1] && d[i][j] == d[i-1][j-1]:
+			matches[i-1] = j - 1
+			i, j = i-1, j-1
+		case d[i][j] == d[i-1][j-1]+1:
+			i, j = i-1, j-1
+		case d[i][j] == d[i-1][j]+1:
+			i--
+		default:
+			j--
+		}
+	}
+	return matches
+}
@@ -0,0 +1,39 @@
+package parfait
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeAtomic produces path through a temporary file in the same directory, renamed into
+// place only when write succeeds, so an interrupted run never leaves a truncated file.
+// The temporary name keeps the extension for tools that pick the format from it (ffmpeg).
+// Renaming also replaces a hard link instead of writing through it.
+func writeAtomic(path string, write func(tmp string) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".*-"+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	f.Close()
+	if err := write(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic is os.WriteFile through writeAtomic
+func writeFileAtomic(path string, data []byte) error {
+	return writeAtomic(path, func(tmp string) error {
+		return os.WriteFile(tmp, data, 0644)
+	})
+}
@@ -0,0 +1,293 @@
+package parfait
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+// WAV format tags of the fmt chunk
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE // read as PCM; the subformat is not exposed by the decoder
+)
+
+// floatBitDepth is the PCM depth IEEE float audio is converted to
+const floatBitDepth = 24
+
+// pcm8Offset centres 8-bit WAV samples, which are unsigned (0..255), on zero like every
+// other depth; go-audio leaves them unsigned on both decode and encode
+const pcm8Offset = 128
+
+// pcmToBuffer converts little-endian PCM bytes (32/24/16-bit signed or 8-bit unsigned) to samples
+func pcmToBuffer(pcmData []byte, channels, sampleRate, bitsPerSample int) *audio.IntBuffer {
+	bytesPerSample := bitsPerSample / 8
+	numSamples := len(pcmData) / bytesPerSample
+
+	buf := &audio.IntBuffer{
+		Data:           make([]int, numSamples),
+		Format:         &audio.Format{SampleRate: sampleRate, NumChannels: channels},
+		SourceBitDepth: bitsPerSample,
+	}
+	for i := 0; i < numSamples; i++ {
+		offset := i * bytesPerSample
+		switch bitsPerSample {
+		case 32:
+			buf.Data[i] = int(int32(binary.LittleEndian.Uint32(pcmData[offset:])))
+		case 24:
+			buf.Data[i] = int(audio.Int24LETo32(pcmData[offset : offset+3]))
+		case 16:
+			buf.Data[i] = int(int16(binary.LittleEndian.Uint16(pcmData[offset:])))
+		case 8:
+			buf.Data[i] = int(pcmData[offset]) - 128 // 8-bit is unsigned
+		}
+	}
+	return buf
+}
+
+// decodeWAV decodes a WAV file returned by a provider
+func decodeWAV(data []byte) (*audio.IntBuffer, error) {
+	dec := wav.NewDecoder(bytes.NewReader(data))
+	if !dec.IsValidFile() {
+		return nil, fmt.Errorf("provider did not return a valid WAV file")
+	}
+	buf, err := dec.FullPCMBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode WAV: %v", err)
+	}
+	buf.SourceBitDepth = int(dec.BitDepth)
+	switch dec.WavAudioFormat {
+	case wavFormatPCM, wavFormatExtensible:
+		if dec.BitDepth == 8 {
+			for i := range buf.Data {
+				buf.Data[i] -= pcm8Offset
+			}
+		}
+	case wavFormatFloat:
+		if dec.BitDepth != 32 {
+			return nil, fmt.Errorf("unsupported WAV format: %d-bit float", dec.BitDepth)
+		}
+		floatToPCM(buf, floatBitDepth)
+	default:
+		return nil, fmt.Errorf("unsupported WAV format tag %d", dec.WavAudioFormat)
+	}
+	return buf, nil
+}
+
+// floatToPCM converts samples decoded as raw 32-bit float bit patterns to signed PCM of
+// the given depth; values beyond [-1, 1] are clipped
+func floatToPCM(buf *audio.IntBuffer, bitDepth int) {
+	full := float64(int(1) << (bitDepth - 1))
+	for i, v := range buf.Data {
+		f := float64(math.Float32frombits(uint32(int32(v))))
+		buf.Data[i] = int(math.Round(math.Max(-full, math.Min(full-1, f*full))))
+	}
+	buf.SourceBitDepth = bitDepth
+}
+
+// silenceSampleCount is the number of interleaved samples in d of silence
+func silenceSampleCount(d time.Duration, sampleRate, channels int) int {
+	return int(d.Seconds()*float64(sampleRate)) * channels
+}
+
+// prependSilence inserts d of silence before the start of buf
+func prependSilence(buf *audio.IntBuffer, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	buf.Data = append(make([]int, silenceSampleCount(d, buf.Format.SampleRate, buf.Format.NumChannels)), buf.Data...)
+}
+
+// appendSilence extends buf with d of silence
+func appendSilence(buf *audio.IntBuffer, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	buf.Data = append(buf.Data, make([]int, silenceSampleCount(d, buf.Format.SampleRate, buf.Format.NumChannels))...)
+}
+
+// applyFades ramps the first fadeIn and last fadeOut of buf linearly from and to silence
+func applyFades(buf *audio.IntBuffer, fadeIn, fadeOut time.Duration) {
+	channels := buf.Format.NumChannels
+	frames := len(buf.Data) / channels
+	in := min(frames, silenceSampleCount(fadeIn, buf.Format.SampleRate, 1))
+	out := min(frames, silenceSampleCount(fadeOut, buf.Format.SampleRate, 1))
+	for i := range in {
+		for ch := range channels {
+			buf.Data[i*channels+ch] = buf.Data[i*channels+ch] * i / in
+		}
+	}
+	for i := range out {
+		frame := frames - 1 - i
+		for ch := range channels {
+			buf.Data[frame*channels+ch] = buf.Data[frame*channels+ch] * i / out
+		}
+	}
+}
+
+// applyGain multiplies every sample by gain, clamping to the sample range
+func applyGain(buf *audio.IntBuffer, gain float64) {
+	maxValue := float64(int(1)<<(buf.SourceBitDepth-1) - 1)
+	minValue := -maxValue - 1
+	for i, v := range buf.Data {
+		buf.Data[i] = int(math.Round(math.Max(minValue, math.Min(maxValue, float64(v)*gain))))
+	}
+}
+
+// peakLevel is the largest absolute sample of buf relative to full scale (1 = 0 dBFS)
+func peakLevel(buf *audio.IntBuffer) float64 {
+	full := float64(int(1) << (buf.SourceBitDepth - 1))
+	peak := 0.0
+	for _, v := range buf.Data {
+		peak = math.Max(peak, math.Abs(float64(v))/full)
+	}
+	return peak
+}
+
+// dbToGain converts decibels to a linear amplitude factor
+func dbToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// normalizePeak scales buf so its loudest sample sits at dbfs; silent audio is left as-is
+func normalizePeak(buf *audio.IntBuffer, dbfs float64) {
+	if peak := peakLevel(buf); peak > 0 {
+		applyGain(buf, dbToGain(dbfs)/peak)
+	}
+}
+
+// convertChannels duplicates mono into every channel or averages multi-channel audio
+// down to mono; buf is returned as-is when it already has that many channels
+func convertChannels(buf *audio.IntBuffer, channels int) *audio.IntBuffer {
+	from := buf.Format.NumChannels
+	if channels == 0 || channels == from {
+		return buf
+	}
+	frames := len(buf.Data) / from
+	out := &audio.IntBuffer{
+		Data:           make([]int, frames*channels),
+		Format:         &audio.Format{SampleRate: buf.Format.SampleRate, NumChannels: channels},
+		SourceBitDepth: buf.SourceBitDepth,
+	}
+	for i := range frames {
+		var sum int
+		for _, v := range buf.Data[i*from : (i+1)*from] {
+			sum += v
+		}
+		for ch := range channels {
+			if from == 1 || channels == 1 {
+				out.Data[i*channels+ch] = sum / from
+			} else {
+				out.Data[i*channels+ch] = buf.Data[i*from+min(ch, from-1)]
+			}
+		}
+	}
+	return out
+}
+
+// convertBitDepth rescales the samples of buf to bitDepth
+func convertBitDepth(buf *audio.IntBuffer, bitDepth int) {
+	from := buf.SourceBitDepth
+	if from == bitDepth {
+		return
+	}
+	for i, v := range buf.Data {
+		if bitDepth > from {
+			buf.Data[i] = v << (bitDepth - from)
+		} else {
+			buf.Data[i] = v >> (from - bitDepth)
+		}
+	}
+	buf.SourceBitDepth = bitDepth
+}
+
+// audioPart is a synthesized clip or a pause
+type audioPart struct {
+	clip  *audio.IntBuffer
+	pause time.Duration
+	// crossfade blends the start of clip over this much of the audio before it
+	crossfade time.Duration
+}
+
+// joinAudio concatenates clips and pauses; all clips must share one format. A crossfade is
+// shortened to the lengths of the audio so far and of the clip.
+func joinAudio(parts []audioPart) (*audio.IntBuffer, error) {
+	var first *audio.IntBuffer
+	for _, part := range parts {
+		if part.clip != nil {
+			first = part.clip
+			break
+		}
+	}
+	if first == nil {
+		return nil, fmt.Errorf("no audio to join")
+	}
+
+	out := &audio.IntBuffer{
+		Format:         &audio.Format{SampleRate: first.Format.SampleRate, NumChannels: first.Format.NumChannels},
+		SourceBitDepth: first.SourceBitDepth,
+	}
+	for _, part := range parts {
+		clip := part.clip
+		if clip == nil {
+			appendSilence(out, part.pause)
+			continue
+		}
+		if clip.Format.SampleRate != out.Format.SampleRate || clip.Format.NumChannels != out.Format.NumChannels || clip.SourceBitDepth != out.SourceBitDepth {
+			return nil, fmt.Errorf("provider returned mixed audio formats (%d Hz, %d ch, %d bit and %d Hz, %d ch, %d bit)",
+				out.Format.SampleRate, out.Format.NumChannels, out.SourceBitDepth,
+				clip.Format.SampleRate, clip.Format.NumChannels, clip.SourceBitDepth)
+		}
+		channels := out.Format.NumChannels
+		n := min(silenceSampleCount(part.crossfade, out.Format.SampleRate, 1), len(out.Data)/channels, len(clip.Data)/channels)
+		tail := out.Data[len(out.Data)-n*channels:]
+		for i := range tail {
+			frame := i / channels
+			tail[i] = (tail[i]*(n-frame) + clip.Data[i]*frame) / n
+		}
+		out.Data = append(out.Data, clip.Data[n*channels:]...)
+	}
+	return out, nil
+}
+
+// writeWAVBuffer encodes buf as a PCM WAV file, with a LIST-INFO chunk when meta is set.
+// The file appears only once it is complete (see writeAtomic).
+func writeWAVBuffer(filename string, buf *audio.IntBuffer, meta riffInfo) error {
+	return writeAtomic(filename, func(tmp string) error {
+		file, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		enc := wav.NewEncoder(file, buf.Format.SampleRate, buf.SourceBitDepth, buf.Format.NumChannels, 1) // 1 = PCM format
+		out := buf
+		if buf.SourceBitDepth == 8 {
+			out = &audio.IntBuffer{Data: make([]int, len(buf.Data)), Format: buf.Format, SourceBitDepth: 8}
+			for i, v := range buf.Data {
+				out.Data[i] = v + pcm8Offset
+			}
+		}
+		if err := enc.Write(out); err != nil {
+			return fmt.Errorf("failed to write audio data: %v", err)
+		}
+		if meta != nil {
+			// after the samples, like the encoder's own metadata, so that readers
+			// without LIST support still find the data chunk
+			if err := enc.AddBE(meta.chunk()); err != nil {
+				return fmt.Errorf("failed to write metadata: %v", err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		return file.Close()
+	})
+}
@@ -0,0 +1,43 @@
+package parfait
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/go-audio/audio"
+)
+
+func TestWAV8BitRoundTrip(t *testing.T) {
+	samples := []int{-128, -1, 0, 1, 127}
+	buf := &audio.IntBuffer{
+		Data:           slices.Clone(samples),
+		Format:         &audio.Format{SampleRate: 8000, NumChannels: 1},
+		SourceBitDepth: 8,
+	}
+	path := filepath.Join(t.TempDir(), "8bit.wav")
+	if err := writeWAVBuffer(path, buf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 8-bit WAV samples are stored unsigned, centred on 128
+	if want := []byte{0, 127, 128, 129, 255}; !slices.Equal(data[44:49], want) {
+		t.Errorf("stored samples = %v, want %v", data[44:49], want)
+	}
+
+	got, err := readSlideWAV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.Data, samples) {
+		t.Errorf("decoded samples = %v, want %v", got.Data, samples)
+	}
+	if got.SourceBitDepth != 8 {
+		t.Errorf("bit depth = %d, want 8", got.SourceBitDepth)
+	}
+}
@@ -0,0 +1,74 @@
+package parfait
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-audio/audio"
+)
+
+// Defaults of the background music flags
+const (
+	defaultBGMVolume  = -20.0 // dB relative to the music file
+	defaultBGMFadeOut = 3 * time.Second
+)
+
+// bgmOptions describes background music looped under the deck narration
+type bgmOptions struct {
+	File    string // "" = none
+	Volume  float64
+	FadeOut time.Duration
+}
+
+func (b bgmOptions) validate() error {
+	if b.File == "" {
+		return nil
+	}
+	if _, err := os.Stat(b.File); err != nil {
+		return fmt.Errorf("background music: %v", err)
+	}
+	if b.FadeOut < 0 {
+		return fmt.Errorf("background music fade-out must not be negative")
+	}
+	return checkFFmpeg("--bgm")
+}
+
+// mixBackground loops the music under buf for its whole length, ducking the music while
+// the narration speaks and fading it out at the end
+func mixBackground(ctx context.Context, buf *audio.IntBuffer, bgm bgmOptions) (*audio.IntBuffer, error) {
+	dir, err := os.MkdirTemp("", "parfait-bgm-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "narration.wav")
+	out := filepath.Join(dir, "mixed.wav")
+	if err := writeWAVBuffer(in, buf, nil); err != nil {
+		return nil, err
+	}
+	layout := "mono"
+	if buf.Format.NumChannels > 1 {
+		layout = "stereo"
+	}
+	fade := "anull"
+	if bgm.FadeOut > 0 {
+		start := max(wavDuration(buf)-bgm.FadeOut, 0)
+		fade = fmt.Sprintf("afade=t=out:st=%.3f:d=%.3f", start.Seconds(), bgm.FadeOut.Seconds())
+	}
+	graph := fmt.Sprintf("[0:a]asplit=2[voice][key];"+
+		"[1:a]aresample=%d,aformat=channel_layouts=%s,volume=%gdB[music];"+
+		"[music][key]sidechaincompress=threshold=0.02:ratio=10:attack=20:release=500[ducked];"+
+		"[ducked]%s[bed];"+
+		"[voice][bed]amix=inputs=2:duration=first:normalize=0[out]",
+		buf.Format.SampleRate, layout, bgm.Volume, fade)
+	err = runFFmpeg(ctx, "-i", in, "-stream_loop", "-1", "-i", bgm.File,
+		"-filter_complex", graph, "-map", "[out]", "-codec:a", pcmCodec(buf.SourceBitDepth), out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mix background music: %v", err)
+	}
+	return readSlideWAV(out)
+}
@@ -0,0 +1,51 @@
+package parfait
+
+import (
+	"fmt"
+	"sync"
+)
+
+const defaultBreakerThreshold = 3
+
+// circuitBreaker stops sending work to a provider after too many consecutive failures,
+// so the remaining slides don't each wait out a full timeout against a dead service.
+type circuitBreaker struct {
+	mu          sync.Mutex
+	threshold   int
+	consecutive int
+	open        bool
+}
+
+// newCircuitBreaker returns a breaker that trips after threshold consecutive failures.
+// A threshold of 0 or less disables it.
+func newCircuitBreaker(threshold int) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold}
+}
+
+// Open reports whether the breaker has tripped
+func (b *circuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// Record registers the outcome of a request and reports whether this call tripped the breaker.
+func (b *circuitBreaker) Record(err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.consecutive = 0
+		return false
+	}
+	b.consecutive++
+	if b.threshold > 0 && !b.open && b.consecutive >= b.threshold {
+		b.open = true
+		return true
+	}
+	return false
+}
+
+// Err describes the tripped breaker for the run summary
+func (b *circuitBreaker) Err() error {
+	return fmt.Errorf("local TTS failed %d times in a row; circuit breaker tripped", b.threshold)
+}
@@ -0,0 +1,35 @@
+package parfait
+
+import (
+	"fmt"
+	"os"
+)
+
+// maxCharsEnv provides a default per-run character ceiling (see `config set budget.max_chars`).
+const maxCharsEnv = "PARFAIT_MAX_CHARS"
+
+// runEstimate summarizes the provider work a run will perform
+type runEstimate struct {
+	Slides int
+	Chars  int
+}
+
+func estimateRun(notes []SlideNote) runEstimate {
+	est := runEstimate{Slides: len(notes)}
+	for _, note := range notes {
+		est.Chars += len([]rune(stripPauseMarkers(note.Note)))
+	}
+	return est
+}
+
+// checkBudget aborts runs whose estimate exceeds the configured ceiling
+func checkBudget(est runEstimate, opts ttsOptions) error {
+	if opts.MaxChars <= 0 || est.Chars <= opts.MaxChars {
+		return nil
+	}
+	if opts.AllowOverBudget {
+		fmt.Fprintf(os.Stderr, "Warning: run exceeds budget (%d chars > %d), continuing because --allow-over-budget is set\n", est.Chars, opts.MaxChars)
+		return nil
+	}
+	return fmt.Errorf("run exceeds budget: %d slides, %d chars > limit of %d chars. Re-run with --allow-over-budget to proceed", est.Slides, est.Chars, opts.MaxChars)
+}
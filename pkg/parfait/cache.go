@@ -0,0 +1,108 @@
+package parfait
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-audio/audio"
+)
+
+// defaultCacheDir is created in the output directory unless the global cache is used
+const defaultCacheDir = ".parfait-cache"
+
+// ttsCache stores provider audio under a hash of everything that shapes it, so
+// unchanged text is not synthesized again. The zero value is a disabled cache.
+type ttsCache struct {
+	dir string
+}
+
+// cacheKey hashes the text with the provider, model or server, voice, language, style
+// and speaking rate of the request
+func cacheKey(useGemini bool, text string, opts ttsOptions) string {
+	request := struct {
+		Provider string  `json:"provider"`
+		Model    string  `json:"model"`
+		Voice    string  `json:"voice"`
+		Language string  `json:"language"`
+		Style    string  `json:"style,omitempty"`
+		Speed    float64 `json:"speed,omitempty"`
+		Text     string  `json:"text"`
+	}{
+		Provider: "kokovox",
+		Model:    getKokoVoxURL(),
+		Voice:    opts.Voice,
+		Language: kokoVoxLanguage(opts.Language),
+		Speed:    opts.Speed,
+		Text:     text,
+	}
+	if useGemini {
+		request.Provider = "gemini"
+		request.Model = geminiTTSModel
+		request.Voice = cmp.Or(opts.Voice, defaultGeminiVoice)
+		request.Language = opts.Language
+		request.Style = opts.Style
+		request.Speed = 0
+	}
+	b, _ := json.Marshal(request)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c ttsCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".wav")
+}
+
+// lookup returns the path of the cached WAV file of key and marks it as used for cache prune
+func (c ttsCache) lookup(key string) (string, bool) {
+	if c.dir == "" {
+		return "", false
+	}
+	path := c.path(key)
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// loadClip returns the cached audio of key
+func (c ttsCache) loadClip(key string) (*audio.IntBuffer, bool) {
+	path, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	clip, err := readSlideWAV(path)
+	return clip, err == nil
+}
+
+// storeFile saves a copy of the provider WAV file at path under key
+func (c ttsCache) storeFile(key, path string) {
+	c.save(key, func(tmp string) error { return streamFile(path, tmp) })
+}
+
+// storeClip saves audio under key as a WAV file
+func (c ttsCache) storeClip(key string, clip *audio.IntBuffer) {
+	c.save(key, func(tmp string) error { return writeWAVBuffer(tmp, clip, nil) })
+}
+
+// save writes an entry atomically, so concurrent slides and interrupted runs never
+// leave a partial entry. Failures only cost a later cache miss.
+func (c ttsCache) save(key string, write func(tmp string) error) {
+	if c.dir == "" {
+		return
+	}
+	path := c.path(key)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = writeAtomic(path, write)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to cache audio: %v\n", err)
+	}
+}
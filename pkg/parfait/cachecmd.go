@@ -0,0 +1,192 @@
+package parfait
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultPruneOlder is the default --older-than of cache prune
+const defaultPruneOlder = 30 * 24 * time.Hour
+
+var (
+	cacheDirArgFlag  string
+	pruneOlderThan   time.Duration
+	pruneMaxSizeFlag string
+)
+
+// globalCacheDir is the cache shared by all decks, under the user cache directory
+func globalCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "parfait", "tts"), nil
+}
+
+// cacheEntry is one cached clip; its modification time is when it was last used
+type cacheEntry struct {
+	path    string
+	size    int64
+	lastUse time.Time
+}
+
+// listCache returns the entries of dir, least recently used first. Leftover temporary
+// files count as entries so prune removes them.
+func listCache(dir string) ([]cacheEntry, error) {
+	var entries []cacheEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || (!strings.HasSuffix(path, ".wav") && !strings.HasSuffix(path, ".tmp")) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, cacheEntry{path: path, size: info.Size(), lastUse: info.ModTime()})
+		return nil
+	})
+	slices.SortFunc(entries, func(a, b cacheEntry) int { return a.lastUse.Compare(b.lastUse) })
+	return entries, err
+}
+
+// parseSize reads sizes such as 500M or 2GB (binary units)
+func parseSize(s string) (int64, error) {
+	t := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+	unit := int64(1)
+	for i, suffix := range []string{"K", "M", "G", "T"} {
+		if strings.HasSuffix(t, suffix) {
+			unit = 1 << (10 * (i + 1))
+			t = strings.TrimSuffix(t, suffix)
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(t, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q: use e.g. 500M or 2G", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// formatSize prints a byte count in binary units
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+func cacheCmdDir() (string, error) {
+	if cacheDirArgFlag != "" {
+		return cacheDirArgFlag, nil
+	}
+	return globalCacheDir()
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean the synthesis cache",
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Print the size of the synthesis cache",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cacheCmdDir()
+		if err != nil {
+			return err
+		}
+		entries, err := listCache(dir)
+		if err != nil {
+			return err
+		}
+		var total int64
+		for _, e := range entries {
+			total += e.size
+		}
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Cache: %s\n", dir)
+		fmt.Fprintf(out, "Entries: %d (%s)\n", len(entries), formatSize(total))
+		if len(entries) > 0 {
+			fmt.Fprintf(out, "Least recently used: %s\n", entries[0].lastUse.Format(time.DateTime))
+			fmt.Fprintf(out, "Most recently used: %s\n", entries[len(entries)-1].lastUse.Format(time.DateTime))
+		}
+		return nil
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cache entries not used recently or beyond a size limit",
+	Long: `Remove cache entries not used recently or beyond a size limit.
+
+Entries unused for --older-than are removed first; with --max-size the least
+recently used entries are then removed until the cache fits.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cacheCmdDir()
+		if err != nil {
+			return err
+		}
+		maxSize := int64(-1)
+		if pruneMaxSizeFlag != "" {
+			if maxSize, err = parseSize(pruneMaxSizeFlag); err != nil {
+				return err
+			}
+		}
+		entries, err := listCache(dir)
+		if err != nil {
+			return err
+		}
+
+		var total int64
+		for _, e := range entries {
+			total += e.size
+		}
+		cutoff := time.Now().Add(-pruneOlderThan)
+		removed, freed := 0, int64(0)
+		for _, e := range entries {
+			stale := pruneOlderThan > 0 && e.lastUse.Before(cutoff)
+			if !stale && (maxSize < 0 || total <= maxSize) {
+				// Entries are oldest first, so the rest are kept
+				break
+			}
+			if err := os.Remove(e.path); err != nil {
+				return err
+			}
+			total -= e.size
+			freed += e.size
+			removed++
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Removed %d entries (%s), %s left\n", removed, formatSize(freed), formatSize(total))
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.PersistentFlags().StringVar(&cacheDirArgFlag, "dir", "", "Cache directory (default: the global cache under the user cache directory)")
+	cachePruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", defaultPruneOlder, "Remove entries not used for this long (0 = keep regardless of age)")
+	cachePruneCmd.Flags().StringVar(&pruneMaxSizeFlag, "max-size", "", "Then remove least recently used entries until the cache is at most this size, e.g. 500M")
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+}
@@ -0,0 +1,53 @@
+package parfait
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// deckChapter is one slide's span in the full-deck audio
+type deckChapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// chapterFormats are the deck audio formats that can carry chapter markers
+// (ID3v2 CHAP frames in MP3, chapter atoms in MP4)
+var chapterFormats = []string{audioFormatMP3, audioFormatM4A}
+
+// displayTitle is the chapter and track title of a slide
+func (n SlideNote) displayTitle() string {
+	if n.Title != "" {
+		return n.Title
+	}
+	return fmt.Sprintf("Slide %d", n.SlideNumber)
+}
+
+// writeFFMetadata writes chapters in ffmpeg's metadata file format to a temporary file
+func writeFFMetadata(dir string, chapters []deckChapter) (string, error) {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for _, c := range chapters {
+		fmt.Fprintf(&b, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			c.Start.Milliseconds(), c.End.Milliseconds(), escapeFFMetadata(c.Title))
+	}
+
+	f, err := os.CreateTemp(dir, ".chapters-*.txt")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// escapeFFMetadata escapes the characters that are special in ffmpeg metadata files
+func escapeFFMetadata(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n").Replace(s)
+}
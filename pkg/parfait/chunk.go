@@ -0,0 +1,110 @@
+package parfait
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Longest text sent in one synthesis request; longer notes are split into chunks
+const (
+	geminiChunkChars  = 3000
+	kokoVoxChunkChars = 1000
+)
+
+var (
+	// sentenceEndPattern ends a sentence: terminal punctuation with any closing quotes, or a line break
+	sentenceEndPattern = regexp.MustCompile(`[.!?。！？]+["'”’)\]」』）]*(?:\s+|$)|\n+`)
+	// clauseEndPattern is the fallback for a single sentence over the limit
+	clauseEndPattern = regexp.MustCompile(`[,;:、，；：]\s*`)
+)
+
+// chunkLimit returns the chunk size for the provider unless opts sets one
+func chunkLimit(opts ttsOptions, useGemini bool) int {
+	if opts.MaxChunkChars > 0 {
+		return opts.MaxChunkChars
+	}
+	if useGemini {
+		return geminiChunkChars
+	}
+	return kokoVoxChunkChars
+}
+
+// chunkText splits text into pieces of at most limit characters, breaking at sentence
+// boundaries where possible, then at clauses, then at spaces
+func chunkText(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		current.Reset()
+	}
+	for _, sentence := range splitAfterPattern(text, sentenceEndPattern) {
+		if utf8.RuneCountInString(current.String())+utf8.RuneCountInString(sentence) <= limit {
+			current.WriteString(sentence)
+			continue
+		}
+		flush()
+		if utf8.RuneCountInString(sentence) <= limit {
+			current.WriteString(sentence)
+			continue
+		}
+		for _, clause := range splitAfterPattern(sentence, clauseEndPattern) {
+			if utf8.RuneCountInString(current.String())+utf8.RuneCountInString(clause) > limit {
+				flush()
+			}
+			for utf8.RuneCountInString(clause) > limit {
+				head, rest := splitAtRune(clause, limit)
+				current.WriteString(head)
+				flush()
+				clause = rest
+			}
+			current.WriteString(clause)
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitAfterPattern splits s after every match of pattern, keeping the matches
+func splitAfterPattern(s string, pattern *regexp.Regexp) []string {
+	var parts []string
+	start := 0
+	for _, m := range pattern.FindAllStringIndex(s, -1) {
+		if m[1] > start {
+			parts = append(parts, s[start:m[1]])
+			start = m[1]
+		}
+	}
+	if start < len(s) {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// splitAtRune cuts s to at most limit characters, preferring the last space before the cut
+func splitAtRune(s string, limit int) (string, string) {
+	cut := len(s)
+	n := 0
+	for i := range s {
+		if n == limit {
+			cut = i
+			break
+		}
+		n++
+	}
+	if sp := strings.LastIndexAny(s[:cut], " \t"); sp > 0 {
+		cut = sp + 1
+	}
+	return s[:cut], s[cut:]
+}
@@ -0,0 +1,564 @@
+package parfait
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+)
+
+var (
+	geminiFlag   bool
+	providerFlag string
+	languageFlag string
+	toneFlag     string
+	outputFlag   string
+	yesFlag      bool
+
+	retryAttemptsFlag   int
+	retryDelayFlag      time.Duration
+	retryMaxDelayFlag   time.Duration
+	retryJitterFlag     float64
+	retryMaxElapsedFlag time.Duration
+
+	proxyFlag         string
+	ttsTimeoutFlag    time.Duration
+	healthTimeoutFlag time.Duration
+	slideTimeoutFlag  time.Duration
+	runTimeoutFlag    time.Duration
+
+	breakerThresholdFlag int
+	fallbackFlag         string
+
+	maxCharsFlag        int
+	allowOverBudgetFlag bool
+
+	segmentPauseFlag    time.Duration
+	lexiconFlag         string
+	normalizeFlag       bool
+	urlsFlag            string
+	acronymsFlag        string
+	emojiFlag           string
+	stripMarkdownFlag   bool
+	notesFallbackFlag   string
+	deckFormatFlag      string
+	skipCommentsFlag    string
+	delimiterFlag       string
+	generateNotesFlag   bool
+	slideBudgetFlag     time.Duration
+	speechRatesFlag     string
+	maxChunkFlag        int
+	audioFormatFlag     string
+	bitrateFlag         string
+	qualityFlag         int
+	presetFlag          string
+	denoiseFlag         string
+	denoiseModelFlag    string
+	audioFiltersFlag    string
+	takesFlag           int
+	reviewFlag          bool
+	bgmFlag             string
+	bgmVolumeFlag       float64
+	bgmFadeFlag         time.Duration
+	crossfadeFlag       time.Duration
+	introFlag           string
+	outroFlag           string
+	subtitlesFlag       string
+	trailingSilenceFlag string
+	leadingSilenceFlag  time.Duration
+	loudnessFlag        float64
+	gainFlag            float64
+	sampleRateFlag      int
+	channelsFlag        int
+	tempoFlag           float64
+	fadeFlag            time.Duration
+	deckAudioFlag       bool
+	chaptersFlag        bool
+	overridesFlag       string
+	cacheDirFlag        string
+	noCacheFlag         bool
+	globalCacheFlag     bool
+	concurrencyFlag     int
+	qaFlag              string
+	qaModelFlag         string
+	qaThresholdFlag     float64
+	alignFlag           string
+	alignModelFlag      string
+	normalizePeakFlag   float64
+	outputPatternFlag   string
+	numberStartFlag     int
+	numberPaddingFlag   int
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "parfait <deck-file|url>",
+	Short: "Generate TTS audio from markdown slides",
+	Long: `Parfait generates Text-to-Speech audio files from markdown presentation files.
+Each slide's HTML comments (<!-- -->) are converted to speech.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return run(cmd.Context(), args[0], cmd.Flags().Changed)
+	},
+}
+
+func init() {
+	// Load .env file (optional, only if it exists)
+	if _, err := os.Stat(".env"); err == nil {
+		if err := godotenv.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Error loading .env file: %v\n", err)
+		}
+	}
+
+	rootCmd.Flags().BoolVarP(&geminiFlag, "gemini", "g", false, "Use Gemini API for TTS (default: parfait.provider in the frontmatter, else local TTS)")
+	rootCmd.Flags().StringVar(&providerFlag, "provider", "", "TTS provider: kokovox|gemini, overriding parfait.provider in the frontmatter (--gemini is --provider gemini)")
+	rootCmd.Flags().StringVarP(&languageFlag, "lang", "l", "", "Language for TTS as a BCP-47 tag, e.g. ja, en, en-GB, fr (default: parfait.language in the frontmatter)")
+	rootCmd.Flags().StringVar(&toneFlag, "tone", "", "Narration preset setting voice, style, pacing and pauses: "+strings.Join(toneNames(), "|")+" (default: parfait.tone in the frontmatter)")
+	rootCmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Output directory for WAV files (default: same directory as input file, or the current directory for a URL)")
+	rootCmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "Skip confirmation prompts (overwriting outputs, costly runs)")
+
+	retry := defaultRetryPolicy()
+	rootCmd.Flags().IntVar(&retryAttemptsFlag, "retries", retry.MaxAttempts, "Max attempts per slide request (Gemini always tries every key at least once)")
+	rootCmd.Flags().DurationVar(&retryDelayFlag, "retry-delay", retry.BaseDelay, "Initial backoff before the first retry (doubles each retry)")
+	rootCmd.Flags().DurationVar(&retryMaxDelayFlag, "retry-max-delay", retry.MaxDelay, "Maximum backoff between retries")
+	rootCmd.Flags().Float64Var(&retryJitterFlag, "retry-jitter", retry.Jitter, "Random backoff jitter as a fraction (0-1)")
+	rootCmd.Flags().DurationVar(&retryMaxElapsedFlag, "retry-max-elapsed", retry.MaxElapsed, "Stop retrying a slide after this long (0 = no limit)")
+
+	rootCmd.Flags().StringVar(&proxyFlag, "proxy", "", "Proxy URL for KokoVox and Gemini requests (default: HTTP_PROXY/HTTPS_PROXY, NO_PROXY is honored)")
+	rootCmd.Flags().DurationVar(&ttsTimeoutFlag, "tts-timeout", 0, "Timeout for a single TTS request (default: PARFAIT_TTS_TIMEOUT or 30s)")
+	rootCmd.Flags().DurationVar(&healthTimeoutFlag, "health-timeout", 0, "Timeout for the KokoVox health check (default: PARFAIT_HEALTH_TIMEOUT or 5s)")
+	rootCmd.Flags().DurationVar(&slideTimeoutFlag, "slide-timeout", 0, "Give up on a slide after this long, including retries (0 = no limit)")
+	rootCmd.Flags().DurationVar(&runTimeoutFlag, "run-timeout", 0, "Abort the whole run after this long (0 = no limit)")
+	rootCmd.Flags().IntVar(&breakerThresholdFlag, "breaker-threshold", defaultBreakerThreshold, "Stop using local TTS after this many consecutive slide failures (0 = never)")
+	rootCmd.Flags().StringVar(&fallbackFlag, "fallback", "", "Provider to switch to when the local TTS breaker trips (gemini; default: abort)")
+	rootCmd.Flags().IntVar(&maxCharsFlag, "max-chars", 0, "Abort if the notes exceed this many characters (default: PARFAIT_MAX_CHARS or no limit)")
+	rootCmd.Flags().DurationVar(&segmentPauseFlag, "segment-pause", 0, "Pause between the comments of a slide with several (default: parfait.segment_pause in the frontmatter or 500ms)")
+	rootCmd.Flags().StringVar(&lexiconFlag, "lexicon", "", "YAML/JSON file mapping terms to readings per language, applied before synthesis")
+	rootCmd.Flags().BoolVar(&normalizeFlag, "normalize", true, "Expand numbers, dates, versions and units into words for ja/en (--normalize=false to send text as written)")
+	rootCmd.Flags().StringVar(&urlsFlag, "urls", urlPolicyKeep, "How to read URLs in notes: "+strings.Join(urlPolicies, "|")+" (refer says \"see the link on the slide\")")
+	rootCmd.Flags().StringVar(&acronymsFlag, "acronyms", "", "Project acronym table (lexicon file format) merged over the built-in defaults, e.g. SQL: sequel")
+	rootCmd.Flags().StringVar(&emojiFlag, "emoji", emojiPolicyKeep, "How to handle emoji in notes: "+strings.Join(emojiPolicies, "|")+" (name reads e.g. \"rocket emoji\" in English, strips otherwise)")
+	rootCmd.Flags().BoolVar(&stripMarkdownFlag, "strip-markdown", true, "Read markdown in notes as plain text, e.g. [docs](url) as \"docs\" (--strip-markdown=false to send it as written)")
+	rootCmd.Flags().StringVar(&notesFallbackFlag, "notes-fallback", notesFallbackError, "What to do with slides without a comment: error|body (body narrates the heading and bullet text)")
+	rootCmd.Flags().StringVar(&deckFormatFlag, "deck-format", deckFormatAuto, "Deck dialect: "+strings.Join(deckFormats, "|")+" (auto detects from the file extension and headmatter)")
+	rootCmd.Flags().StringVar(&delimiterFlag, "delimiter", "", "Only split Marp slides at this line: ---, ***, ___ or a comment such as \"<!-- slide -->\" (default: any rule; parfait.delimiter in the frontmatter)")
+	rootCmd.Flags().StringVar(&skipCommentsFlag, "skip-comments", "", "Regular expression for comments that are not narration (Marp directives such as <!-- _class: lead --> are always skipped)")
+	rootCmd.Flags().BoolVar(&generateNotesFlag, "generate-missing-notes", false, "Write Gemini-generated notes into the deck for slides without a comment (shows a diff and asks first)")
+	rootCmd.Flags().DurationVar(&slideBudgetFlag, "slide-budget", 0, "Warn about slides whose notes take longer than this to read (0 = off)")
+	rootCmd.Flags().StringVar(&audioFormatFlag, "format", audioFormatWAV, "Slide audio file format: "+strings.Join(audioFormats, "|")+" (all but wav need ffmpeg)")
+	rootCmd.Flags().StringVar(&bitrateFlag, "bitrate", defaultBitrate, "Bitrate of compressed audio formats, e.g. 96k")
+	rootCmd.Flags().IntVar(&qualityFlag, "quality", -1, "Encode mp3 as VBR at this LAME quality, 0 (best) to 9, instead of --bitrate (-1 = off)")
+	rootCmd.Flags().StringVar(&presetFlag, "preset", "", "Output preset: "+strings.Join(audioPresetNames(), "|")+" (sets bitrate, sample rate, channels, loudness and fade unless given)")
+	rootCmd.Flags().StringVar(&trailingSilenceFlag, "trailing-silence", "", "Silence appended to each slide, e.g. 0.5s or 0s for none (default: 1s for Gemini, none for KokoVox; overrides --tone and the frontmatter)")
+	rootCmd.Flags().DurationVar(&leadingSilenceFlag, "leading-silence", 0, "Silence before each slide's narration, e.g. 300ms (per slide: leading_silence)")
+	rootCmd.Flags().Float64Var(&loudnessFlag, "loudness", 0, "Normalize each slide to this integrated loudness in LUFS (EBU R128), e.g. -16 (0 = off)")
+	rootCmd.Flags().IntVar(&sampleRateFlag, "sample-rate", 0, "Resample all slide audio to this rate in Hz, e.g. 48000, so mixed providers can be concatenated (0 = provider's rate)")
+	rootCmd.Flags().IntVar(&channelsFlag, "channels", 0, "Write slide audio as mono (1) or stereo (2); mono narration is copied to both channels (0 = provider's)")
+	rootCmd.Flags().Float64Var(&tempoFlag, "speed", 1, "Speed narration up or down after synthesis without changing the pitch, e.g. 1.1 (works with every provider)")
+	rootCmd.Flags().DurationVar(&fadeFlag, "fade", 0, "Fade each slide's narration in and out over this long to avoid clicks where slides are joined, e.g. 10ms")
+	rootCmd.Flags().StringVar(&outputPatternFlag, "output-pattern", "", "Slide file names: a printf pattern such as %03d.wav or a template such as {{.Lang}}-{{.Slide}}-{{.TitleSlug}}.wav (default: parfait.output in the frontmatter, else the padded slide number + .wav)")
+	rootCmd.Flags().IntVar(&numberStartFlag, "number-start", 1, "Number the first slide's file with this, e.g. 0 for 000.wav")
+	rootCmd.Flags().IntVar(&numberPaddingFlag, "number-padding", defaultNumberPadding, "Zero-pad slide numbers in file names to this many digits (default and template names)")
+	rootCmd.Flags().StringVar(&denoiseFlag, "denoise", "", "Reduce hiss in synthesized slides with ffmpeg: "+strings.Join(denoiseMethods, "|")+" (rnnoise needs --denoise-model)")
+	rootCmd.Flags().StringVar(&denoiseModelFlag, "denoise-model", "", "RNNoise model file (.rnnn) for --denoise rnnoise")
+	rootCmd.Flags().StringVar(&audioFiltersFlag, "audio-filters", "", "ffmpeg -af filter chain run on every synthesized slide, e.g. \"highpass=f=80,acompressor\"")
+	rootCmd.Flags().IntVar(&takesFlag, "takes", 1, "Synthesize each slide this many times into 001.take1.wav, 001.take2.wav, ...; 001.wav is the first take")
+	rootCmd.Flags().BoolVar(&reviewFlag, "review", false, "After synthesis, play each slide and accept, regenerate or edit its text (player: PARFAIT_PLAYER, else ffplay, afplay, paplay or aplay)")
+	rootCmd.Flags().StringVar(&subtitlesFlag, "subtitles", "", "Write the notes as captions timed to the narration, audio-<lang>.<format>: "+strings.Join(subtitleFormats, "|"))
+	rootCmd.Flags().BoolVar(&deckAudioFlag, "deck-audio", false, "Also write the whole deck as one narration track, audio-<lang>.<format>")
+	rootCmd.Flags().DurationVar(&crossfadeFlag, "crossfade", 0, "Crossfade consecutive slides by this much in the --deck-audio track, e.g. 300ms")
+	rootCmd.Flags().StringVar(&introFlag, "intro", "", "Audio clip to put before the --deck-audio narration, e.g. a jingle (non-WAV files need ffmpeg)")
+	rootCmd.Flags().StringVar(&outroFlag, "outro", "", "Audio clip to put after the --deck-audio narration")
+	rootCmd.Flags().StringVar(&bgmFlag, "bgm", "", "Loop this music file under the --deck-audio track, ducked while the narration speaks (needs ffmpeg)")
+	rootCmd.Flags().Float64Var(&bgmVolumeFlag, "bgm-volume", defaultBGMVolume, "Background music level in dB relative to the file")
+	rootCmd.Flags().DurationVar(&bgmFadeFlag, "bgm-fade", defaultBGMFadeOut, "Fade the background music out over the end of the track")
+	rootCmd.Flags().BoolVar(&chaptersFlag, "chapters", false, "Add a chapter per slide, named after its title, to the --deck-audio track (needs --format mp3 or m4a)")
+	rootCmd.Flags().StringVar(&overridesFlag, "overrides", "", "Directory of hand recordings used instead of synthesis, named like the output (007.wav replaces slide 7) (default: overrides/ next to the deck)")
+	rootCmd.Flags().IntVarP(&concurrencyFlag, "concurrency", "j", defaultTTSConcurrency, "Number of slides synthesized in parallel (1 = one at a time)")
+	rootCmd.Flags().StringVar(&qaFlag, "qa", "", "Transcribe the narration and flag slides that differ from the notes: "+strings.Join(qaBackends, "|")+" (default: off)")
+	rootCmd.Flags().StringVar(&qaModelFlag, "qa-model", "", "whisper.cpp model file for --qa whisper-cpp, or the model for --qa openai (default "+defaultOpenAIQAModel+")")
+	rootCmd.Flags().Float64Var(&qaThresholdFlag, "qa-threshold", defaultQAThreshold, "Flag slides whose transcript matches less than this share of the notes")
+	rootCmd.Flags().StringVar(&alignFlag, "align", "", "Time --subtitles to the words heard in the narration: "+strings.Join(qaBackends, "|")+" (default: off, proportional timing)")
+	rootCmd.Flags().StringVar(&alignModelFlag, "align-model", "", "whisper.cpp model file for --align whisper-cpp, or the model for --align openai (default "+defaultOpenAIQAModel+")")
+	rootCmd.Flags().StringVar(&cacheDirFlag, "cache-dir", "", "Directory caching synthesized audio by text, provider, voice and language (default: "+defaultCacheDir+" in the output directory)")
+	rootCmd.Flags().BoolVar(&globalCacheFlag, "global-cache", false, "Use the cache shared by all decks under the user cache directory (see parfait cache)")
+	rootCmd.Flags().BoolVar(&noCacheFlag, "no-cache", false, "Synthesize every slide again instead of reusing cached audio")
+	rootCmd.Flags().Float64Var(&gainFlag, "gain", 0, "Amplify (or attenuate, if negative) each slide by this many dB; samples beyond full scale are clipped")
+	rootCmd.Flags().Float64Var(&normalizePeakFlag, "normalize-peak", 0, "Scale each slide so its loudest sample sits at this level in dBFS, e.g. -1 (0 = off)")
+	rootCmd.Flags().IntVar(&maxChunkFlag, "max-chunk-chars", 0, fmt.Sprintf("Split longer notes at sentence boundaries into requests of at most this many characters (default: %d for Gemini, %d for KokoVox)", geminiChunkChars, kokoVoxChunkChars))
+	rootCmd.Flags().StringVar(&speechRatesFlag, "chars-per-second", "", "Speech rate overrides for --slide-budget per language, e.g. ja=7,en=15")
+	rootCmd.Flags().BoolVar(&allowOverBudgetFlag, "allow-over-budget", false, "Proceed even if the run exceeds --max-chars")
+
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// run generates a deck's audio; changed reports whether a flag was set explicitly
+func run(ctx context.Context, mdFile string, changed func(flag string) bool) error {
+	// Remote decks are downloaded; their audio goes to the current directory by default
+	defaultOutputDir := filepath.Dir(mdFile)
+	if isRemoteDeck(mdFile) {
+		if generateNotesFlag {
+			return fmt.Errorf("--generate-missing-notes needs a local deck to write the notes into")
+		}
+		local, cleanup, err := downloadDeck(ctx, mdFile, proxyFlag)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		mdFile = local
+		defaultOutputDir = "."
+	}
+
+	// Validate markdown file exists
+	if _, err := os.Stat(mdFile); os.IsNotExist(err) {
+		return fmt.Errorf("markdown file '%s' does not exist", mdFile)
+	}
+
+	// Validate file extension
+	if !slices.Contains(deckExtensions, strings.ToLower(filepath.Ext(mdFile))) {
+		return fmt.Errorf("file '%s' is not a slide deck (%s)", mdFile, strings.Join(deckExtensions, ", "))
+	}
+
+	// Deck settings from the frontmatter; flags take precedence
+	deck, err := readDeckConfig(mdFile)
+	if err != nil {
+		return err
+	}
+	if providerFlag != "" && providerFlag != "kokovox" && providerFlag != "gemini" {
+		return fmt.Errorf("invalid provider: %q. Use kokovox or gemini", providerFlag)
+	}
+	if geminiFlag && providerFlag == "kokovox" {
+		return fmt.Errorf("--gemini conflicts with --provider kokovox")
+	}
+	useGemini := geminiFlag || cmp.Or(providerFlag, deck.Provider) == "gemini"
+
+	// Validate language; provider support is checked once the provider is known
+	if languageFlag == "" && deck.Language == "" {
+		return fmt.Errorf("language is required: use --lang or parfait.language in the frontmatter")
+	}
+	lang, err := normalizeLanguage(cmp.Or(languageFlag, deck.Language))
+	if err != nil {
+		return err
+	}
+	if useGemini || fallbackFlag == "gemini" {
+		if err := validateGeminiLanguage(lang); err != nil {
+			return err
+		}
+	}
+	var lex *lexicon
+	if lexiconFlag != "" {
+		if lex, err = loadLexicon(lexiconFlag, lang); err != nil {
+			return err
+		}
+	}
+	// Built-in acronyms are part of normalization; a project table always applies
+	acronyms, err := loadAcronyms(acronymsFlag, lang, normalizeFlag)
+	if err != nil {
+		return err
+	}
+	tone := cmp.Or(toneFlag, deck.Tone)
+	if err := validateTone(tone); err != nil {
+		return err
+	}
+
+	retry := retryPolicy{
+		MaxAttempts: retryAttemptsFlag,
+		BaseDelay:   retryDelayFlag,
+		MaxDelay:    retryMaxDelayFlag,
+		Jitter:      retryJitterFlag,
+		MaxElapsed:  retryMaxElapsedFlag,
+	}
+	if err := retry.validate(); err != nil {
+		return err
+	}
+	if err := validateProxyURL(proxyFlag); err != nil {
+		return err
+	}
+	if fallbackFlag != "" && fallbackFlag != "gemini" {
+		return fmt.Errorf("invalid fallback: %s. Use gemini", fallbackFlag)
+	}
+
+	// Determine output directory
+	outputDir := cmp.Or(outputFlag, defaultOutputDir)
+	if err := validateAudioFormat(audioFormatFlag, bitrateFlag, qualityFlag); err != nil {
+		return err
+	}
+	if err := validateAudioPreset(presetFlag); err != nil {
+		return err
+	}
+	denoise := denoiseOptions{Method: denoiseFlag, Model: denoiseModelFlag}
+	if err := denoise.validate(); err != nil {
+		return err
+	}
+	if audioFiltersFlag != "" {
+		if err := checkFFmpeg("--audio-filters"); err != nil {
+			return err
+		}
+	}
+	trailingSilence, trailingSilenceSet, err := parseTrailingSilence(trailingSilenceFlag)
+	if err != nil {
+		return err
+	}
+	if leadingSilenceFlag < 0 {
+		return fmt.Errorf("leading silence must not be negative")
+	}
+	if loudnessFlag > 0 || (loudnessFlag != 0 && loudnessFlag < minLoudnessTarget) {
+		return fmt.Errorf("invalid loudness: %g LUFS. Use a target between %d and 0, e.g. -16", loudnessFlag, minLoudnessTarget)
+	}
+	if err := validateSampleRate(sampleRateFlag); err != nil {
+		return err
+	}
+	if channelsFlag < 0 || channelsFlag > 2 {
+		return fmt.Errorf("invalid channels: %d. Use 1 (mono) or 2 (stereo)", channelsFlag)
+	}
+	if err := validateOutputPattern(outputPatternFlag); err != nil {
+		return err
+	}
+	if numberStartFlag < 0 {
+		return fmt.Errorf("number start must not be negative")
+	}
+	if numberPaddingFlag < 1 || numberPaddingFlag > 9 {
+		return fmt.Errorf("invalid number padding: %d. Use 1 to 9 digits", numberPaddingFlag)
+	}
+	bgm := bgmOptions{File: bgmFlag, Volume: bgmVolumeFlag, FadeOut: bgmFadeFlag}
+	if err := bgm.validate(); err != nil {
+		return err
+	}
+	if err := validateSubtitleFormat(subtitlesFlag); err != nil {
+		return err
+	}
+	align := transcriber{Backend: alignFlag, Model: alignModelFlag}
+	if err := align.validate("align"); err != nil {
+		return err
+	}
+	if align.Backend != "" && subtitlesFlag == "" {
+		return fmt.Errorf("--align needs --subtitles")
+	}
+	if crossfadeFlag < 0 {
+		return fmt.Errorf("crossfade must not be negative")
+	}
+	if crossfadeFlag > 0 && !deckAudioFlag {
+		return fmt.Errorf("--crossfade needs --deck-audio")
+	}
+	for _, clip := range [][2]string{{"intro", introFlag}, {"outro", outroFlag}} {
+		flag, path := clip[0], clip[1]
+		if err := validateJingle(flag, path); err != nil {
+			return err
+		}
+		if path != "" && !deckAudioFlag {
+			return fmt.Errorf("--%s needs --deck-audio", flag)
+		}
+	}
+	if bgm.File != "" && !deckAudioFlag {
+		return fmt.Errorf("--bgm needs --deck-audio")
+	}
+	if chaptersFlag && (!deckAudioFlag || !slices.Contains(chapterFormats, audioFormatFlag)) {
+		return fmt.Errorf("--chapters needs --deck-audio and --format %s", strings.Join(chapterFormats, " or "))
+	}
+	qa := qaOptions{Backend: qaFlag, Model: qaModelFlag, Threshold: qaThresholdFlag}
+	if err := qa.validate(); err != nil {
+		return err
+	}
+	if takesFlag < 1 || takesFlag > maxTakes {
+		return fmt.Errorf("invalid takes: %d. Use 1 to %d", takesFlag, maxTakes)
+	}
+	if reviewFlag {
+		if err := checkReview(); err != nil {
+			return err
+		}
+	}
+	if concurrencyFlag < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+	if fadeFlag < 0 {
+		return fmt.Errorf("fade must not be negative")
+	}
+	if err := validateTempo(tempoFlag); err != nil {
+		return err
+	}
+	if normalizePeakFlag > 0 {
+		return fmt.Errorf("invalid peak level: %g dBFS. Use a negative level, e.g. -1", normalizePeakFlag)
+	}
+
+	// Fill provider settings from the global config (process env and .env take precedence).
+	// Secrets are only decrypted when Gemini keys are actually needed.
+	if err := applyGlobalEnvDefaults(useGemini || fallbackFlag == "gemini" || generateNotesFlag || qaFlag == qaOpenAI || alignFlag == qaOpenAI); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load global config: %v\n", err)
+	}
+
+	// Budget ceiling: flag wins, otherwise the env / global config default
+	maxChars := maxCharsFlag
+	if maxChars == 0 {
+		if v := os.Getenv(maxCharsEnv); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q", maxCharsEnv, v)
+			}
+			maxChars = n
+		}
+	}
+
+	ttsTimeout, err := resolveTimeout(ttsTimeoutFlag, ttsTimeoutEnv, defaultTTSTimeout)
+	if err != nil {
+		return err
+	}
+	healthTimeout, err := resolveTimeout(healthTimeoutFlag, healthTimeoutEnv, defaultHealthTimeout)
+	if err != nil {
+		return err
+	}
+
+	// Check KokoVox service health if using local TTS
+	if !useGemini {
+		if err := checkKokoVoxHealth(lang, healthTimeout, proxyFlag); err != nil {
+			return err
+		}
+	}
+
+	if slideTimeoutFlag < 0 || runTimeoutFlag < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if err := validateURLPolicy(urlsFlag); err != nil {
+		return err
+	}
+	if err := validateEmojiPolicy(emojiFlag); err != nil {
+		return err
+	}
+	speechRates, err := parseSpeechRates(speechRatesFlag)
+	if err != nil {
+		return err
+	}
+	notesOpts := notesOptions{
+		Fallback:     notesFallbackFlag,
+		Format:       deckFormatForFile(mdFile, deckFormatFlag),
+		SkipComments: skipCommentsFlag,
+		Delimiter:    cmp.Or(delimiterFlag, deck.Delimiter),
+	}
+	if err := notesOpts.validate(); err != nil {
+		return err
+	}
+	if segmentPauseFlag < 0 {
+		return fmt.Errorf("segment pause must not be negative")
+	}
+	if maxChunkFlag < 0 {
+		return fmt.Errorf("max chunk chars must not be negative")
+	}
+	ctx, cancel := withOptionalTimeout(ctx, runTimeoutFlag, "run")
+	defer cancel()
+
+	fmt.Printf("Processing: %s\n", mdFile)
+	fmt.Printf("Output directory: %s\n", outputDir)
+	fmt.Printf("Language: %s\n", lang)
+
+	// Run TTS generation
+	opts := ttsOptions{
+		Language:  lang,
+		UseGemini: useGemini,
+		AssumeYes: yesFlag,
+		Retry:     retry,
+		Proxy:     proxyFlag,
+		Timeout:   ttsTimeout,
+
+		SlideTimeout: slideTimeoutFlag,
+
+		BreakerThreshold: breakerThresholdFlag,
+		Fallback:         fallbackFlag,
+
+		MaxChars:        maxChars,
+		AllowOverBudget: allowOverBudgetFlag,
+
+		Silence:        defaultTrailingSilence(useGemini),
+		LeadingSilence: leadingSilenceFlag,
+		SegmentPause:   defaultSegmentPause,
+		Lexicon:        lex,
+		Acronyms:       acronyms,
+		Normalize:      normalizeFlag,
+		URLPolicy:      urlsFlag,
+		EmojiPolicy:    emojiFlag,
+
+		StripMarkdown: stripMarkdownFlag,
+		Notes:         notesOpts,
+
+		SlideBudget: slideBudgetFlag,
+		SpeechRates: speechRates,
+
+		MaxChunkChars: maxChunkFlag,
+		AudioFormat:   audioFormatFlag,
+		Bitrate:       bitrateFlag,
+		Loudness:      loudnessFlag,
+		SampleRate:    sampleRateFlag,
+		Channels:      channelsFlag,
+		Tempo:         tempoFlag,
+		Fade:          fadeFlag,
+		DeckAudio:     deckAudioFlag,
+		Chapters:      chaptersFlag,
+		OverridesDir:  cmp.Or(overridesFlag, filepath.Join(defaultOutputDir, defaultOverridesDir)),
+		Gain:          gainFlag,
+		NormalizePeak: normalizePeakFlag,
+		Concurrency:   concurrencyFlag,
+		QA:            qa,
+		Takes:         takesFlag,
+		Review:        reviewFlag,
+		BGM:           bgm,
+		Crossfade:     crossfadeFlag,
+		Subtitles:     subtitlesFlag,
+		Align:         align,
+		Intro:         introFlag,
+		Outro:         outroFlag,
+		Denoise:       denoise,
+		AudioFilters:  audioFiltersFlag,
+		NumberPadding: numberPaddingFlag,
+		NumberOffset:  numberStartFlag - 1,
+	}
+	if !noCacheFlag {
+		opts.Cache = ttsCache{dir: cmp.Or(cacheDirFlag, filepath.Join(outputDir, defaultCacheDir))}
+		if globalCacheFlag && cacheDirFlag == "" {
+			dir, err := globalCacheDir()
+			if err != nil {
+				return err
+			}
+			opts.Cache.dir = dir
+		}
+	}
+	if qualityFlag != -1 {
+		opts.Quality = &qualityFlag
+	}
+	applyAudioPreset(&opts, presetFlag, changed)
+	applyTone(&opts, tone)
+	deck.applyTo(&opts)
+	if outputPatternFlag != "" {
+		opts.OutputPattern = outputPatternFlag
+	}
+	if segmentPauseFlag > 0 {
+		opts.SegmentPause = segmentPauseFlag
+	}
+	if trailingSilenceSet {
+		opts.Silence = trailingSilence
+	}
+	if generateNotesFlag {
+		if err := fillMissingNotes(ctx, mdFile, opts); err != nil {
+			return fmt.Errorf("note generation failed: %v", redactErr(err))
+		}
+	}
+
+	if err := Run(ctx, mdFile, outputDir, opts); err != nil {
+		err = annotateTimeout(ctx, err)
+		return fmt.Errorf("TTS generation failed: %v", redactErr(err))
+	}
+
+	return nil
+}
+
+// Execute runs the parfait command line
+func Execute(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
+}
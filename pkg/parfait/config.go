@@ -0,0 +1,491 @@
+package parfait
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type globalConfig struct {
+	// GoogleAPIKeys is preferred (supports rotation). Any number of keys is allowed.
+	GoogleAPIKeys []string `json:"google_api_keys,omitempty"`
+	// GoogleAPIKey is kept for backward compatibility with older config files.
+	GoogleAPIKey string `json:"google_api_key,omitempty"`
+
+	// Per-provider sections, edited with `config set <provider>.<field>`.
+	OpenAI     openAIConfig     `json:"openai,omitzero"`
+	Azure      azureConfig      `json:"azure,omitzero"`
+	ElevenLabs elevenLabsConfig `json:"elevenlabs,omitzero"`
+	KokoVox    kokoVoxConfig    `json:"kokovox,omitzero"`
+
+	// Budget guards shared keys against accidentally huge runs.
+	Budget budgetConfig `json:"budget,omitzero"`
+	// Timeouts are Go durations ("90s", "2m") applied to provider requests.
+	Timeouts timeoutsConfig `json:"timeouts,omitzero"`
+
+	// Encrypted holds the age-encrypted (armored) secret fields after `config encrypt`.
+	// When set, the plaintext secret fields above are left empty on disk.
+	Encrypted string `json:"encrypted,omitempty"`
+	// EncryptedFields names the settings inside Encrypted ("google_api_keys" or
+	// "<provider>.<field>"), so runs can tell whether they need to decrypt at all.
+	EncryptedFields []string `json:"encrypted_fields,omitempty"`
+	// Recipient is the age public key the secrets are encrypted to. Empty means passphrase mode.
+	Recipient string `json:"recipient,omitempty"`
+
+	// passphrase is kept in memory only so edits can be re-encrypted on save.
+	passphrase string
+}
+
+type openAIConfig struct {
+	APIKey  string `json:"api_key,omitempty"`
+	BaseURL string `json:"base_url,omitempty"`
+}
+
+type azureConfig struct {
+	APIKey   string `json:"api_key,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+type elevenLabsConfig struct {
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type kokoVoxConfig struct {
+	URL string `json:"url,omitempty"`
+}
+
+type budgetConfig struct {
+	// MaxChars is kept as a string like every other field; it is parsed when the run starts.
+	MaxChars string `json:"max_chars,omitempty"`
+}
+
+type timeoutsConfig struct {
+	TTS    string `json:"tts,omitempty"`
+	Health string `json:"health,omitempty"`
+}
+
+// configField describes a single `<provider>.<field>` setting.
+type configField struct {
+	key string
+	// env is the environment variable this setting provides a default for.
+	env string
+	// secret fields are masked on display and encrypted by `config encrypt`.
+	secret bool
+	ptr    func(*globalConfig) *string
+}
+
+var configFields = []configField{
+	{"openai.api_key", "OPENAI_API_KEY", true, func(c *globalConfig) *string { return &c.OpenAI.APIKey }},
+	{"openai.base_url", "OPENAI_BASE_URL", false, func(c *globalConfig) *string { return &c.OpenAI.BaseURL }},
+	{"azure.api_key", "AZURE_SPEECH_KEY", true, func(c *globalConfig) *string { return &c.Azure.APIKey }},
+	{"azure.region", "AZURE_SPEECH_REGION", false, func(c *globalConfig) *string { return &c.Azure.Region }},
+	{"azure.endpoint", "AZURE_SPEECH_ENDPOINT", false, func(c *globalConfig) *string { return &c.Azure.Endpoint }},
+	{"elevenlabs.api_key", "ELEVENLABS_API_KEY", true, func(c *globalConfig) *string { return &c.ElevenLabs.APIKey }},
+	{"kokovox.url", "KOKOVOX_URL", false, func(c *globalConfig) *string { return &c.KokoVox.URL }},
+	{"budget.max_chars", maxCharsEnv, false, func(c *globalConfig) *string { return &c.Budget.MaxChars }},
+	{"timeouts.tts", ttsTimeoutEnv, false, func(c *globalConfig) *string { return &c.Timeouts.TTS }},
+	{"timeouts.health", healthTimeoutEnv, false, func(c *globalConfig) *string { return &c.Timeouts.Health }},
+}
+
+func lookupConfigField(key string) (configField, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, f := range configFields {
+		if f.key == key {
+			return f, nil
+		}
+	}
+	known := make([]string, 0, len(configFields))
+	for _, f := range configFields {
+		known = append(known, f.key)
+	}
+	return configField{}, fmt.Errorf("unknown config key %q (known: %s)", key, strings.Join(known, ", "))
+}
+
+func globalConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "parfait", "config.json"), nil
+}
+
+func normalizeKeys(keys []string) []string {
+	seen := make(map[string]struct{}, len(keys))
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, k)
+	}
+	return out
+}
+
+func loadGlobalConfig() (globalConfig, error) {
+	return loadGlobalConfigFile(true)
+}
+
+// loadGlobalConfigFile reads the global config. With decrypt false, encrypted
+// secrets are left untouched so no passphrase is needed.
+func loadGlobalConfigFile(decrypt bool) (globalConfig, error) {
+	p, err := globalConfigPath()
+	if err != nil {
+		return globalConfig{}, err
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return globalConfig{}, nil
+		}
+		return globalConfig{}, err
+	}
+
+	var cfg globalConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return globalConfig{}, fmt.Errorf("invalid config file (%s): %w", p, err)
+	}
+	if decrypt && cfg.Encrypted != "" {
+		if err := decryptGlobalConfig(&cfg); err != nil {
+			return globalConfig{}, fmt.Errorf("failed to decrypt config file (%s): %w", p, err)
+		}
+	}
+
+	// Migrate legacy single key into keys list (in-memory).
+	if len(cfg.GoogleAPIKeys) == 0 && strings.TrimSpace(cfg.GoogleAPIKey) != "" {
+		cfg.GoogleAPIKeys = []string{strings.TrimSpace(cfg.GoogleAPIKey)}
+	}
+	cfg.GoogleAPIKeys = normalizeKeys(cfg.GoogleAPIKeys)
+	return cfg, nil
+}
+
+func saveGlobalConfig(cfg globalConfig) error {
+	p, err := globalConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	if cfg.isEncrypted() {
+		if err := encryptGlobalConfig(&cfg); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	// Best-effort: 0600 for unix-like. Windows ignores permissions.
+	return os.WriteFile(p, b, 0o600)
+}
+
+// hasGoogleKeyEnv reports whether the process env already defines any Google API key.
+func hasGoogleKeyEnv() bool {
+	return len(googleAPIKeysFromEnv()) > 0
+}
+
+// missingSecrets reports whether the encrypted config holds a secret the environment does
+// not define. Configs encrypted before EncryptedFields was recorded are assumed to hold all.
+func (c globalConfig) missingSecrets() bool {
+	if c.EncryptedFields == nil {
+		if !hasGoogleKeyEnv() {
+			return true
+		}
+		for _, f := range configFields {
+			if f.secret && os.Getenv(f.env) == "" {
+				return true
+			}
+		}
+		return false
+	}
+	for _, key := range c.EncryptedFields {
+		if key == "google_api_keys" {
+			if !hasGoogleKeyEnv() {
+				return true
+			}
+			continue
+		}
+		if f, err := lookupConfigField(key); err == nil && os.Getenv(f.env) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyGlobalEnvDefaults loads global config and sets env vars only if they are not already set.
+// Encrypted secrets are only decrypted when withSecrets is true and the environment lacks
+// one of them.
+func applyGlobalEnvDefaults(withSecrets bool) error {
+	cfg, err := loadGlobalConfigFile(false)
+	if err != nil {
+		return err
+	}
+	if cfg.Encrypted != "" && withSecrets && cfg.missingSecrets() {
+		if err := decryptGlobalConfig(&cfg); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range configFields {
+		if os.Getenv(f.env) != "" {
+			continue
+		}
+		if v := *f.ptr(&cfg); v != "" {
+			_ = os.Setenv(f.env, v)
+		}
+	}
+	for _, f := range configFields {
+		if f.secret {
+			registerSecret(os.Getenv(f.env))
+		}
+	}
+
+	// Only set default if process env doesn't already define any key.
+	if !hasGoogleKeyEnv() && len(cfg.GoogleAPIKeys) > 0 {
+		_ = os.Setenv("GOOGLE_API_KEYS", strings.Join(cfg.GoogleAPIKeys, ","))
+	}
+
+	return nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage global configuration",
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print global config file path",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := globalConfigPath()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), p)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <provider>.<field> <VALUE>",
+	Short: "Set a config value",
+	Long: `Set a config value.
+
+Provider settings are addressed as <provider>.<field>, e.g.:
+  parfait config set openai.api_key sk-...
+  parfait config set kokovox.url http://gpu-box:5108`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := lookupConfigField(args[0])
+		if err != nil {
+			return err
+		}
+		value := strings.TrimSpace(args[1])
+		if value == "" {
+			return fmt.Errorf("%s is empty", f.key)
+		}
+
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		*f.ptr(&cfg) = value
+		if err := saveGlobalConfig(cfg); err != nil {
+			return err
+		}
+
+		p, _ := globalConfigPath()
+		fmt.Fprintf(cmd.OutOrStdout(), "Saved %s to %s\n", f.key, p)
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <provider>.<field>",
+	Short: "Print a config value (secrets are masked)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := lookupConfigField(args[0])
+		if err != nil {
+			return err
+		}
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		v := *f.ptr(&cfg)
+		if v == "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "(%s not set)\n", f.key)
+			return nil
+		}
+		if f.secret {
+			v = maskSecret(v)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), v)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <provider>.<field>",
+	Short: "Remove a config value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := lookupConfigField(args[0])
+		if err != nil {
+			return err
+		}
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		*f.ptr(&cfg) = ""
+		if err := saveGlobalConfig(cfg); err != nil {
+			return err
+		}
+
+		p, _ := globalConfigPath()
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %s from %s\n", f.key, p)
+		return nil
+	},
+}
+
+var configSetAPIKeyCmd = &cobra.Command{
+	Use:   "api-key <KEY>",
+	Short: "Set Google Gemini API key globally (replace all keys)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := strings.TrimSpace(args[0])
+		if key == "" {
+			return fmt.Errorf("api key is empty")
+		}
+
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		cfg.GoogleAPIKeys = []string{key}
+		cfg.GoogleAPIKey = "" // legacy field no longer needed
+		if err := saveGlobalConfig(cfg); err != nil {
+			return err
+		}
+
+		p, _ := globalConfigPath()
+		fmt.Fprintf(cmd.OutOrStdout(), "Saved 1 api key to %s\n", p)
+		return nil
+	},
+}
+
+var configAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a config value",
+}
+
+var configAddAPIKeyCmd = &cobra.Command{
+	Use:   "api-key <KEY>",
+	Short: "Add Google Gemini API key globally (append for rotation)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := strings.TrimSpace(args[0])
+		if key == "" {
+			return fmt.Errorf("api key is empty")
+		}
+
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		cfg.GoogleAPIKeys = append(cfg.GoogleAPIKeys, key)
+		cfg.GoogleAPIKeys = normalizeKeys(cfg.GoogleAPIKeys)
+		cfg.GoogleAPIKey = "" // legacy field no longer needed
+
+		if err := saveGlobalConfig(cfg); err != nil {
+			return err
+		}
+
+		p, _ := globalConfigPath()
+		fmt.Fprintf(cmd.OutOrStdout(), "Saved %d api key(s) to %s\n", len(cfg.GoogleAPIKeys), p)
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List config values",
+}
+
+var configListProvidersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "List provider settings (secrets masked)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		n := 0
+		for _, f := range configFields {
+			v := *f.ptr(&cfg)
+			if v == "" {
+				continue
+			}
+			if f.secret {
+				v = maskSecret(v)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s = %s\n", f.key, v)
+			n++
+		}
+		if n == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "(no provider settings)")
+		}
+		return nil
+	},
+}
+
+var configListAPIKeysCmd = &cobra.Command{
+	Use:   "api-keys",
+	Short: "List saved Gemini API keys (masked)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		if len(cfg.GoogleAPIKeys) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "(no api keys set)")
+			return nil
+		}
+		for i, k := range cfg.GoogleAPIKeys {
+			fmt.Fprintf(cmd.OutOrStdout(), "%d: %s\n", i+1, maskSecret(k))
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configSetCmd)
+	configSetCmd.AddCommand(configSetAPIKeyCmd)
+	configCmd.AddCommand(configAddCmd)
+	configAddCmd.AddCommand(configAddAPIKeyCmd)
+	configCmd.AddCommand(configListCmd)
+	configListCmd.AddCommand(configListAPIKeysCmd)
+	configListCmd.AddCommand(configListProvidersCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configDecryptCmd)
+}
@@ -0,0 +1,36 @@
+package parfait
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Runs whose total note length exceeds this many characters are treated as costly
+// when a paid provider is used, and require confirmation.
+const defaultConfirmCharThreshold = 50000
+
+// confirm asks the user a yes/no question on the terminal. assumeYes bypasses the prompt.
+// Without a terminal the operation is refused rather than silently performed.
+func confirm(question string, assumeYes bool) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("%s: refusing without --yes (no terminal to confirm)", question)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,281 @@
+package parfait
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	// configPassphraseEnv supplies the passphrase non-interactively (CI, scripts).
+	configPassphraseEnv = "PARFAIT_CONFIG_PASSPHRASE"
+	// configIdentityEnv points to an age identity file used to decrypt recipient-mode configs.
+	configIdentityEnv = "PARFAIT_CONFIG_IDENTITY"
+)
+
+// promptedPassphrase caches an interactively entered passphrase so a single
+// invocation never asks twice.
+var promptedPassphrase string
+
+// secretConfig is the subset of globalConfig that is encrypted at rest.
+type secretConfig struct {
+	GoogleAPIKeys []string `json:"google_api_keys,omitempty"`
+	// Fields holds secret provider settings keyed by "<provider>.<field>".
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+func (c globalConfig) isEncrypted() bool {
+	return c.Recipient != "" || c.passphrase != ""
+}
+
+// encryptGlobalConfig moves the secret fields of cfg into cfg.Encrypted.
+func encryptGlobalConfig(cfg *globalConfig) error {
+	var recipient age.Recipient
+	if cfg.Recipient != "" {
+		r, err := age.ParseX25519Recipient(cfg.Recipient)
+		if err != nil {
+			return fmt.Errorf("invalid age recipient: %w", err)
+		}
+		recipient = r
+	} else {
+		r, err := age.NewScryptRecipient(cfg.passphrase)
+		if err != nil {
+			return err
+		}
+		recipient = r
+	}
+
+	secrets := secretConfig{GoogleAPIKeys: cfg.GoogleAPIKeys, Fields: map[string]string{}}
+	for _, f := range configFields {
+		if f.secret && *f.ptr(cfg) != "" {
+			secrets.Fields[f.key] = *f.ptr(cfg)
+		}
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(plain); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := aw.Close(); err != nil {
+		return err
+	}
+
+	cfg.Encrypted = buf.String()
+	cfg.EncryptedFields = []string{}
+	if len(secrets.GoogleAPIKeys) > 0 {
+		cfg.EncryptedFields = append(cfg.EncryptedFields, "google_api_keys")
+	}
+	for _, f := range configFields {
+		if _, ok := secrets.Fields[f.key]; ok {
+			cfg.EncryptedFields = append(cfg.EncryptedFields, f.key)
+		}
+	}
+	cfg.GoogleAPIKeys = nil
+	cfg.GoogleAPIKey = ""
+	for _, f := range configFields {
+		if f.secret {
+			*f.ptr(cfg) = ""
+		}
+	}
+	return nil
+}
+
+// decryptGlobalConfig restores the secret fields of cfg from cfg.Encrypted.
+func decryptGlobalConfig(cfg *globalConfig) error {
+	var identity age.Identity
+	passphrase := ""
+	if cfg.Recipient != "" {
+		id, err := loadAgeIdentity()
+		if err != nil {
+			return err
+		}
+		identity = id
+	} else {
+		p, err := readConfigPassphrase(false)
+		if err != nil {
+			return err
+		}
+		id, err := age.NewScryptIdentity(p)
+		if err != nil {
+			return err
+		}
+		identity = id
+		passphrase = p
+	}
+
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(cfg.Encrypted)), identity)
+	if err != nil {
+		return err
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var secrets secretConfig
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("invalid encrypted payload: %w", err)
+	}
+
+	cfg.GoogleAPIKeys = secrets.GoogleAPIKeys
+	for _, f := range configFields {
+		if v, ok := secrets.Fields[f.key]; ok && f.secret {
+			*f.ptr(cfg) = v
+		}
+	}
+	cfg.Encrypted = ""
+	cfg.EncryptedFields = nil
+	cfg.passphrase = passphrase
+	return nil
+}
+
+// loadAgeIdentity reads the X25519 identity referenced by PARFAIT_CONFIG_IDENTITY.
+func loadAgeIdentity() (age.Identity, error) {
+	p := os.Getenv(configIdentityEnv)
+	if p == "" {
+		return nil, fmt.Errorf("config is encrypted to an age key; set %s to the identity file", configIdentityEnv)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity file (%s): %w", p, err)
+	}
+	return ids[0], nil
+}
+
+// readConfigPassphrase returns the passphrase from the environment or prompts on the terminal.
+// When confirm is true the user must type it twice (used when encrypting).
+func readConfigPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(configPassphraseEnv); p != "" {
+		return p, nil
+	}
+	if promptedPassphrase != "" {
+		return promptedPassphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("config is encrypted; set %s or run in a terminal", configPassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Config passphrase: ")
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	p := string(b)
+	if p == "" {
+		return "", fmt.Errorf("passphrase is empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(b) != p {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	promptedPassphrase = p
+	return p, nil
+}
+
+var configEncryptRecipient string
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt saved api keys and provider secrets with a passphrase or age key",
+	Long: `Encrypt the secret fields of the global config file at rest.
+
+Without --recipient a passphrase is prompted for (or read from PARFAIT_CONFIG_PASSPHRASE).
+With --recipient the keys are encrypted to an age public key and decrypted with the
+identity file pointed to by PARFAIT_CONFIG_IDENTITY.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.isEncrypted() {
+			return fmt.Errorf("config is already encrypted")
+		}
+
+		if configEncryptRecipient != "" {
+			if _, err := age.ParseX25519Recipient(configEncryptRecipient); err != nil {
+				return fmt.Errorf("invalid age recipient: %w", err)
+			}
+			cfg.Recipient = configEncryptRecipient
+		} else {
+			p, err := readConfigPassphrase(true)
+			if err != nil {
+				return err
+			}
+			cfg.passphrase = p
+		}
+
+		if err := saveGlobalConfig(cfg); err != nil {
+			return err
+		}
+
+		p, _ := globalConfigPath()
+		fmt.Fprintf(cmd.OutOrStdout(), "Encrypted secrets in %s\n", p)
+		return nil
+	},
+}
+
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Store saved api keys in plaintext again",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		if !cfg.isEncrypted() {
+			return fmt.Errorf("config is not encrypted")
+		}
+		cfg.Recipient = ""
+		cfg.passphrase = ""
+
+		if err := saveGlobalConfig(cfg); err != nil {
+			return err
+		}
+
+		p, _ := globalConfigPath()
+		fmt.Fprintf(cmd.OutOrStdout(), "Decrypted config in %s\n", p)
+		return nil
+	},
+}
+
+func init() {
+	configEncryptCmd.Flags().StringVar(&configEncryptRecipient, "recipient", "", "age public key (age1...) to encrypt to instead of a passphrase")
+}
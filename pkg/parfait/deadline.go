@@ -0,0 +1,36 @@
+package parfait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// timeoutCause records which deadline expired, so nested deadlines report the one that fired
+type timeoutCause struct {
+	what string
+	d    time.Duration
+}
+
+func (e *timeoutCause) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.what, e.d)
+}
+
+// withOptionalTimeout is context.WithTimeout, except that d <= 0 means no deadline.
+// what names the bounded work in error messages ("run", "slide 003", ...).
+func withOptionalTimeout(ctx context.Context, d time.Duration, what string) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, d, &timeoutCause{what: what, d: d})
+}
+
+// annotateTimeout prefixes err with the deadline that expired on ctx, if any
+func annotateTimeout(ctx context.Context, err error) error {
+	var cause *timeoutCause
+	if err != nil && errors.As(context.Cause(ctx), &cause) {
+		return fmt.Errorf("%v: %w", cause, err)
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package parfait
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-audio/audio"
+)
+
+// deckAudioPath returns the path of the full-deck narration, audio-<lang>.<format>
+func deckAudioPath(outputDir string, opts ttsOptions) string {
+	format := opts.AudioFormat
+	if format == "" {
+		format = audioFormatWAV
+	}
+	return filepath.Join(outputDir, fmt.Sprintf("audio-%s.%s", opts.Language, format))
+}
+
+// writeDeckAudio concatenates the slides' WAV files in slide order into one narration
+// track. Each slide file already carries its trailing silence, which becomes the gap
+// between slides. Slides that failed to generate are left out with a warning. With
+// opts.Chapters every slide becomes a chapter named after its title; opts.BGM is mixed in
+// under the narration. With opts.Crossfade each slide fades in over the end of the one before.
+// opts.Intro and opts.Outro frame the track; the returned offset is where the first slide starts.
+func writeDeckAudio(ctx context.Context, notes []SlideNote, outputDir string, opts ttsOptions) (time.Duration, error) {
+	parts := make([]audioPart, 0, len(notes))
+	var chapters []deckChapter
+	var position time.Duration
+	for _, note := range notes {
+		clip, err := readSlideWAV(slideOutputPath(outputDir, note.SlideNumber, opts))
+		if os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: deck audio is missing slide %03d\n", note.SlideNumber)
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("slide %03d: %v", note.SlideNumber, err)
+		}
+		length := wavDuration(clip)
+		overlap := min(opts.Crossfade, position, length)
+		position -= overlap
+		parts = append(parts, audioPart{clip: clip, crossfade: opts.Crossfade})
+		chapters = append(chapters, deckChapter{Title: note.displayTitle(), Start: position, End: position + length})
+		position += length
+	}
+	buf, err := joinAudio(parts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to join deck audio: %v (use --sample-rate and --channels to give every slide one format)", err)
+	}
+	if opts.BGM.File != "" {
+		if buf, err = mixBackground(ctx, buf, opts.BGM); err != nil {
+			return 0, err
+		}
+	}
+	buf, offset, err := addJingles(ctx, buf, opts)
+	if err != nil {
+		return 0, err
+	}
+	for i := range chapters {
+		chapters[i].Start += offset
+		chapters[i].End += offset
+	}
+
+	dst := deckAudioPath(outputDir, opts)
+	if opts.AudioFormat == "" || opts.AudioFormat == audioFormatWAV {
+		if err := writeWAVBuffer(dst, buf, opts.Meta.deckWAVMetadata()); err != nil {
+			return 0, err
+		}
+		fmt.Printf("✓ Saved deck audio: %s\n", dst)
+		return offset, nil
+	}
+
+	tmp, err := os.CreateTemp(outputDir, ".deck-*.wav")
+	if err != nil {
+		return 0, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := writeWAVBuffer(tmp.Name(), buf, nil); err != nil {
+		return 0, err
+	}
+	if !opts.Chapters {
+		chapters = nil
+	}
+	if err := encodeAudio(ctx, tmp.Name(), dst, deckTags(opts.Meta), chapters, opts); err != nil {
+		return 0, fmt.Errorf("failed to encode deck audio: %v", err)
+	}
+	fmt.Printf("✓ Saved deck audio: %s\n", dst)
+	return offset, nil
+}
+
+// wavDuration is the playing time of buf
+func wavDuration(buf *audio.IntBuffer) time.Duration {
+	frames := len(buf.Data) / buf.Format.NumChannels
+	return time.Duration(frames) * time.Second / time.Duration(buf.Format.SampleRate)
+}
+
+// readSlideWAV decodes a slide's WAV file from disk
+func readSlideWAV(path string) (*audio.IntBuffer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeWAV(data)
+}
+
+// deckWAVMetadata tags the full-deck WAV file with the deck's title, author, date and language
+func (m deckMeta) deckWAVMetadata() riffInfo {
+	return riffInfo{
+		{"INAM", m.Title},
+		{"IART", m.Author},
+		{"ICRD", m.Date},
+		{"ILNG", m.Language},
+		{"ISFT", "parfait"},
+	}
+}
+
+// deckTags are the tags of the encoded full-deck audio
+func deckTags(meta deckMeta) [][2]string {
+	return [][2]string{
+		{"title", meta.Title},
+		{"album", meta.Title},
+		{"artist", meta.Author},
+		{"date", meta.Date},
+		{"language", meta.Language},
+	}
+}
@@ -0,0 +1,188 @@
+package parfait
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/frontmatter"
+)
+
+// deckConfig is the `parfait:` block of a deck's frontmatter.
+// Command-line flags take precedence over these settings.
+//
+//	---
+//	title: My talk
+//	parfait:
+//	  provider: gemini
+//	  language: en
+//	  tone: lecture
+//	  voice: Kore
+//	  output: "{{.Deck}}-{{.Slide}}.wav"
+//	  trailing_silence: 1.5s
+//	  segment_pause: 800ms
+//	  delimiter: "<!-- slide -->"
+//	---
+type deckConfig struct {
+	Provider string `yaml:"provider" toml:"provider"`
+	Language string `yaml:"language" toml:"language"`
+	// Tone selects a narration preset (see tonePresets); the other settings override it
+	Tone string `yaml:"tone" toml:"tone"`
+	// Voice is a provider voice name (Gemini prebuilt voice, or a KokoVox voice from /info)
+	Voice string `yaml:"voice" toml:"voice"`
+	// Output is the WAV file name pattern: a printf pattern with one integer verb for the
+	// slide number, or a template (see slideNameData)
+	Output string `yaml:"output" toml:"output"`
+	// TrailingSilence is appended to each slide's audio (Go duration, e.g. "1.5s"; "0s" for
+	// none). Silence is its older name, kept as an alias.
+	TrailingSilence string `yaml:"trailing_silence" toml:"trailing_silence"`
+	Silence         string `yaml:"silence" toml:"silence"`
+	// SegmentPause separates the comments of a slide with several (Go duration)
+	SegmentPause string `yaml:"segment_pause" toml:"segment_pause"`
+	// Delimiter replaces the rules that separate slides (see notesOptions.Delimiter)
+	Delimiter string `yaml:"delimiter" toml:"delimiter"`
+
+	// Meta comes from the top-level title, author and date keys
+	Meta deckMeta `yaml:"-" toml:"-"`
+}
+
+// loadDeckConfig reads the `parfait:` block from the deck frontmatter.
+// Decks without frontmatter or without the block yield an empty config.
+func loadDeckConfig(content []byte) (deckConfig, error) {
+	md := goldmark.New(goldmark.WithExtensions(&frontmatter.Extender{}))
+	ctx := parser.NewContext()
+	md.Parser().Parse(text.NewReader(content), parser.WithContext(ctx))
+
+	data := frontmatter.Get(ctx)
+	if data == nil {
+		return deckConfig{}, nil
+	}
+	var meta struct {
+		Title   any        `yaml:"title" toml:"title"`
+		Author  any        `yaml:"author" toml:"author"`
+		Date    any        `yaml:"date" toml:"date"`
+		Parfait deckConfig `yaml:"parfait" toml:"parfait"`
+	}
+	if err := data.Decode(&meta); err != nil {
+		return deckConfig{}, fmt.Errorf("invalid frontmatter: %v", err)
+	}
+	cfg := meta.Parfait
+	cfg.Meta = deckMeta{Title: metaValue(meta.Title), Author: metaValue(meta.Author), Date: metaValue(meta.Date)}
+	if err := cfg.validate(); err != nil {
+		return deckConfig{}, fmt.Errorf("invalid parfait frontmatter: %w", err)
+	}
+	return cfg, nil
+}
+
+func (c deckConfig) validate() error {
+	if c.Provider != "" && c.Provider != "kokovox" && c.Provider != "gemini" {
+		return fmt.Errorf("invalid provider: %q. Use kokovox or gemini", c.Provider)
+	}
+	if c.Language != "" {
+		if _, err := normalizeLanguage(c.Language); err != nil {
+			return err
+		}
+	}
+	if err := validateTone(c.Tone); err != nil {
+		return err
+	}
+	if err := validateOutputPattern(c.Output); err != nil {
+		return err
+	}
+	if _, err := c.trailingSilence(); err != nil {
+		return err
+	}
+	if _, err := c.segmentPause(); err != nil {
+		return err
+	}
+	if err := validateDelimiter(c.Delimiter); err != nil {
+		return err
+	}
+	return nil
+}
+
+// trailingSilence parses the silence after each slide (-1 = not set)
+func (c deckConfig) trailingSilence() (time.Duration, error) {
+	if c.TrailingSilence != "" && c.Silence != "" {
+		return 0, fmt.Errorf("trailing_silence and silence are the same setting; use trailing_silence only")
+	}
+	value := cmp.Or(c.TrailingSilence, c.Silence)
+	if value == "" {
+		return -1, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid trailing_silence %q: use a duration such as 1.5s, or 0s for none", value)
+	}
+	return d, nil
+}
+
+// segmentPause parses the pause between segments (-1 = not set)
+func (c deckConfig) segmentPause() (time.Duration, error) {
+	if c.SegmentPause == "" {
+		return -1, nil
+	}
+	d, err := time.ParseDuration(c.SegmentPause)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid segment_pause %q: use a duration such as 500ms", c.SegmentPause)
+	}
+	return d, nil
+}
+
+// validateOutputPattern checks a WAV name pattern such as "intro-%03d.wav" or
+// "{{.Lang}}-{{.Slide}}.wav"
+func validateOutputPattern(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	if isTemplatePattern(pattern) {
+		return validateTemplatePattern(pattern)
+	}
+	if strings.ContainsAny(pattern, `/\`) {
+		return fmt.Errorf("invalid output pattern %q: must be a file name, not a path", pattern)
+	}
+	if strings.Contains(pattern, "{title}") || strings.Contains(pattern, "{author}") || strings.Contains(pattern, "{date}") {
+		return fmt.Errorf("invalid output pattern %q: use a template such as {{.Deck}}-{{.Slide}}.wav for the title, author or date", pattern)
+	}
+	name := fmt.Sprintf(pattern, 1)
+	if strings.Contains(name, "%!") || name == fmt.Sprintf(pattern, 2) {
+		return fmt.Errorf("invalid output pattern %q: must contain one slide number verb such as %%03d", pattern)
+	}
+	if !strings.EqualFold(filepath.Ext(name), ".wav") {
+		return fmt.Errorf("invalid output pattern %q: must end in .wav", pattern)
+	}
+	return nil
+}
+
+// applyTo overrides synthesis options with the settings the deck specifies
+func (c deckConfig) applyTo(opts *ttsOptions) {
+	opts.Meta = c.Meta
+	opts.Meta.Language = opts.Language
+	if c.Voice != "" {
+		opts.Voice = c.Voice
+	}
+	if c.Output != "" {
+		opts.OutputPattern = c.Output
+	}
+	if d, _ := c.trailingSilence(); d >= 0 {
+		opts.Silence = d
+	}
+	if d, _ := c.segmentPause(); d >= 0 {
+		opts.SegmentPause = d
+	}
+}
+
+// readDeckConfig loads the `parfait:` block of a markdown file
+func readDeckConfig(mdFile string) (deckConfig, error) {
+	content, err := os.ReadFile(mdFile)
+	if err != nil {
+		return deckConfig{}, fmt.Errorf("failed to read markdown file: %v", err)
+	}
+	return loadDeckConfig(content)
+}
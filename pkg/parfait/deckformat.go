@@ -0,0 +1,164 @@
+package parfait
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/frontmatter"
+)
+
+// Values of notesOptions.Format
+const (
+	deckFormatAuto   = "auto"
+	deckFormatMarp   = "marp"
+	deckFormatSlidev = "slidev"
+	deckFormatReveal = "reveal"
+	deckFormatQuarto = "quarto"
+	deckFormatPPTX   = "pptx"
+)
+
+var deckFormats = []string{deckFormatAuto, deckFormatMarp, deckFormatSlidev, deckFormatReveal, deckFormatQuarto, deckFormatPPTX}
+
+func validateDeckFormat(format string) error {
+	switch format {
+	case "", deckFormatAuto, deckFormatMarp, deckFormatSlidev, deckFormatReveal, deckFormatQuarto, deckFormatPPTX:
+		return nil
+	}
+	return fmt.Errorf("invalid deck format: %q. Use %s", format, strings.Join(deckFormats, ", "))
+}
+
+// deckExtensions are the input files parfait reads
+var deckExtensions = []string{".md", ".qmd", ".html", ".htm", ".pptx"}
+
+// deckFormatForFile picks the format from the file extension when it is not given
+func deckFormatForFile(path, format string) string {
+	if format != "" && format != deckFormatAuto {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".qmd":
+		return deckFormatQuarto
+	case ".html", ".htm":
+		return deckFormatReveal
+	case ".pptx":
+		return deckFormatPPTX
+	}
+	return deckFormatAuto
+}
+
+// resolveDeckFormat turns "auto" (or "") into a concrete format: zip archives are PowerPoint
+// decks, HTML documents are reveal.js decks, markdown is Quarto or Slidev if the headmatter
+// says so and Marp otherwise
+func resolveDeckFormat(content []byte, format string) string {
+	if format != "" && format != deckFormatAuto {
+		return format
+	}
+	if isPPTX(content) {
+		return deckFormatPPTX
+	}
+	if isRevealHTML(content) {
+		return deckFormatReveal
+	}
+
+	md := goldmark.New(goldmark.WithExtensions(&frontmatter.Extender{}))
+	ctx := parser.NewContext()
+	md.Parser().Parse(text.NewReader(content), parser.WithContext(ctx))
+	data := frontmatter.Get(ctx)
+	if data == nil {
+		return deckFormatMarp
+	}
+	var head map[string]any
+	if err := data.Decode(&head); err != nil {
+		return deckFormatMarp
+	}
+	if marp, _ := head["marp"].(bool); marp {
+		return deckFormatMarp
+	}
+	if isQuartoRevealFormat(head["format"]) {
+		return deckFormatQuarto
+	}
+	for _, k := range slidevHeadmatterKeys {
+		if _, ok := head[k]; ok {
+			return deckFormatSlidev
+		}
+	}
+	return deckFormatMarp
+}
+
+// acceptsCommentNotes reports whether narration of the format is written as <!-- --> comments,
+// so generated notes can be inserted into the source
+func acceptsCommentNotes(format string) bool {
+	return format == deckFormatMarp || format == deckFormatSlidev
+}
+
+// parseDeck splits a deck into slides and drops comments that are not narration:
+// Marp directives in Marp decks and those matching opts.SkipComments. For markdown
+// decks the returned source is what the slide nodes point into; it has the same byte
+// offsets as content.
+func parseDeck(content []byte, opts notesOptions) ([]slideInfo, []byte, error) {
+	format := resolveDeckFormat(content, opts.Format)
+	slides, source, err := splitDeck(content, format, opts.Delimiter)
+	if err != nil {
+		return nil, nil, err
+	}
+	var skip *regexp.Regexp
+	if opts.SkipComments != "" {
+		if skip, err = regexp.Compile(opts.SkipComments); err != nil {
+			return nil, nil, fmt.Errorf("invalid skip-comments pattern: %v", err)
+		}
+	}
+	for i := range slides {
+		slides[i].comments = slices.DeleteFunc(slides[i].comments, func(c string) bool {
+			return (format == deckFormatMarp && isMarpDirective(c)) || (skip != nil && skip.MatchString(c))
+		})
+	}
+	return slides, source, nil
+}
+
+func splitDeck(content []byte, format, delimiter string) ([]slideInfo, []byte, error) {
+	switch format {
+	case deckFormatPPTX:
+		slides, err := pptxSlideInfos(content)
+		return slides, content, err
+	case deckFormatReveal:
+		slides, err := revealSlideInfos(content)
+		return slides, content, err
+	case deckFormatQuarto:
+		// Headmatter is regular YAML frontmatter; every # and ## heading starts a slide
+		source := maskQuartoSource(content)
+		md := goldmark.New(goldmark.WithExtensions(&frontmatter.Extender{}))
+		doc := md.Parser().Parse(text.NewReader(source))
+		slides := splitNodesByThematicBreak(doc, source, 2)
+		for i := range slides {
+			slides[i].title = quartoSlideTitle(slides[i].title)
+			for j, b := range slides[i].body {
+				slides[i].body[j] = quartoSlideTitle(b)
+			}
+		}
+		return slides, source, nil
+	case deckFormatSlidev:
+		source := maskSlidevSource(content)
+		doc := goldmark.New().Parser().Parse(text.NewReader(source))
+		return splitNodesByThematicBreak(doc, source, 0), source, nil
+	}
+	// The frontmatter extension excludes the front matter from the AST
+	source := maskDelimiters(content, delimiter)
+	md := goldmark.New(goldmark.WithExtensions(&frontmatter.Extender{}))
+	doc := md.Parser().Parse(text.NewReader(source))
+	return splitNodesByThematicBreak(doc, source, 0), source, nil
+}
+
+// blankLine replaces every byte of line except its line ending with a space
+func blankLine(line []byte) {
+	for i, c := range line {
+		if c != '\n' && c != '\r' {
+			line[i] = ' '
+		}
+	}
+}
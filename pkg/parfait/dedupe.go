@@ -0,0 +1,106 @@
+package parfait
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// dedupeNotes splits notes into the ones to synthesize and the repeats.
+// reuse maps a repeated slide to the first slide with the same narration
+// (title/agenda slides repeated per section) and directives; whitespace differences are ignored.
+func dedupeNotes(notes []SlideNote) (unique []SlideNote, reuse map[int]int) {
+	reuse = make(map[int]int)
+	first := make(map[string]int, len(notes))
+	for _, note := range notes {
+		// The JSON form compares the optional silences by value rather than by pointer
+		directives, _ := json.Marshal(note.Directives)
+		key := string(directives)
+		for _, segment := range note.speechSegments() {
+			key += "\x00" + strings.Join(strings.Fields(segment), " ")
+		}
+		if src, ok := first[key]; ok {
+			reuse[note.SlideNumber] = src
+			continue
+		}
+		first[key] = note.SlideNumber
+		unique = append(unique, note)
+	}
+	return unique, reuse
+}
+
+// reuseDuplicateAudio gives each repeated slide the audio of its source slide.
+// Untagged WAV files are hard-linked (copied where links are not supported); tagged
+// ones are rewritten so each slide keeps its own title and track number.
+// Repeats of a slide that failed to generate are reported as failed too.
+func reuseDuplicateAudio(outputDir string, notes []SlideNote, reuse map[int]int, opts ttsOptions, hooks ProgressHooks) {
+	for _, note := range notes {
+		src, ok := reuse[note.SlideNumber]
+		if !ok {
+			continue
+		}
+		srcPath := slideOutputPath(outputDir, src, opts)
+		dst := slideOutputPath(outputDir, note.SlideNumber, opts)
+		var err error
+		if !opts.Meta.tagsSlides() {
+			err = linkFile(srcPath, dst)
+		} else {
+			err = retagWAV(srcPath, dst, opts.Meta.wavMetadata(note))
+		}
+		if err != nil {
+			err = fmt.Errorf("reusing audio of slide %03d: %w", src, err)
+			fmt.Fprintf(os.Stderr, "Warning: failed to generate TTS for slide %03d: %v\n", note.SlideNumber, err)
+		} else {
+			fmt.Printf("✓ Slide %03d reuses audio of slide %03d\n", note.SlideNumber, src)
+		}
+		hooks.OnSlideDone(note.SlideNumber, dst, err)
+	}
+}
+
+// linkFile hard-links dst to src, replacing dst, and falls back to copying
+func linkFile(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return copyFile(src, dst)
+}
+
+// retagWAV writes the audio of src to dst with other INFO tags
+func retagWAV(src, dst string, meta riffInfo) error {
+	buf, err := readSlideWAV(src)
+	if err != nil {
+		return err
+	}
+	return writeWAVBuffer(dst, buf, meta)
+}
+
+// copyFile copies src to dst atomically without reading it into memory
+func copyFile(src, dst string) error {
+	return writeAtomic(dst, func(tmp string) error { return streamFile(src, tmp) })
+}
+
+// streamFile copies src to a new file dst
+func streamFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,73 @@
+package parfait
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// slideCommentDelimiter is the usual comment delimiter for decks that don't use rules
+const slideCommentDelimiter = "<!-- slide -->"
+
+func validateDelimiter(delimiter string) error {
+	switch {
+	case delimiter == "", delimiter == "---", delimiter == "***", delimiter == "___":
+		return nil
+	case strings.HasPrefix(delimiter, "<!--") && strings.HasSuffix(delimiter, "-->"):
+		return nil
+	}
+	return fmt.Errorf("invalid slide delimiter: %q. Use ---, ***, ___ or a comment such as %s", delimiter, slideCommentDelimiter)
+}
+
+// maskDelimiters makes delimiter the only slide break of a Marp-style deck, without moving
+// any byte: lines equal to delimiter become `***` rules and other rules are blanked.
+// Rules inside fenced code and `---` underlining a heading are left alone, as is the
+// frontmatter. An empty delimiter keeps the default of splitting at every rule.
+func maskDelimiters(content []byte, delimiter string) []byte {
+	if delimiter == "" {
+		return content
+	}
+	source := bytes.Clone(content)
+	lines := bytes.SplitAfter(source, []byte("\n"))
+
+	i := 0
+	if len(lines) > 0 && isSlidevSeparator(lines[0]) {
+		for i = 1; i < len(lines) && !isSlidevSeparator(lines[i]); i++ {
+		}
+		i++
+	}
+
+	fence := ""
+	prevBlank := true
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(string(line))
+		blank := prevBlank
+		prevBlank = trimmed == ""
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		isRule := thematicBreakPattern.MatchString(strings.TrimRight(string(line), "\r\n"))
+		switch {
+		case trimmed == delimiter:
+			blankLine(line)
+			copy(line, "***")
+			// A rule can't follow paragraph text directly; the break must not join it
+			prevBlank = true
+		case isRule && strings.HasPrefix(trimmed, "-") && !blank:
+			// Setext heading underline
+		case isRule:
+			blankLine(line)
+			prevBlank = true
+		}
+	}
+	return source
+}
@@ -0,0 +1,120 @@
+package parfait
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// directivePrefix marks a comment as synthesis directives rather than narration:
+//
+//	<!-- parfait: voice=Kore rate=0.9 leading_silence=500ms trailing_silence=2s -->
+//
+// A slide meant to be viewed quietly uses `silence=5s` instead of narration.
+const directivePrefix = "parfait:"
+
+// slideDirectives are per-slide overrides of the run's synthesis options
+type slideDirectives struct {
+	Voice string `json:"voice,omitempty"`
+	// Rate is the speaking rate (1 = normal)
+	Rate float64 `json:"rate,omitempty"`
+	// LeadingSilence and TrailingSilence override the run's silences when set, including
+	// to zero to turn them off for the slide (nil = not set)
+	LeadingSilence  *time.Duration `json:"leading_silence,omitempty"`
+	TrailingSilence *time.Duration `json:"trailing_silence,omitempty"`
+	// Silence makes the slide pure silence of this length instead of narration
+	Silence time.Duration `json:"silence,omitempty"`
+}
+
+// MarshalJSON writes durations as strings ("2s") so exported notes stay readable
+func (d slideDirectives) MarshalJSON() ([]byte, error) {
+	type plain slideDirectives
+	out := struct {
+		plain
+		LeadingSilence  string `json:"leading_silence,omitempty"`
+		TrailingSilence string `json:"trailing_silence,omitempty"`
+		Silence         string `json:"silence,omitempty"`
+	}{plain: plain(d)}
+	if d.LeadingSilence != nil {
+		out.LeadingSilence = d.LeadingSilence.String()
+	}
+	if d.TrailingSilence != nil {
+		out.TrailingSilence = d.TrailingSilence.String()
+	}
+	if d.Silence > 0 {
+		out.Silence = d.Silence.String()
+	}
+	return json.Marshal(out)
+}
+
+// isDirectiveComment reports whether a comment body holds directives
+func isDirectiveComment(comment string) bool {
+	return strings.HasPrefix(comment, directivePrefix)
+}
+
+// parseDirectives merges the key=value pairs of a directive comment into d
+func (d *slideDirectives) parse(comment string) error {
+	for _, field := range strings.Fields(strings.TrimPrefix(comment, directivePrefix)) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return fmt.Errorf("invalid directive %q: use key=value", field)
+		}
+		switch key {
+		case "voice":
+			d.Voice = value
+		case "rate":
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate <= 0 {
+				return fmt.Errorf("invalid rate %q: use a positive number such as 0.9", value)
+			}
+			d.Rate = rate
+		case "leading_silence":
+			silence, err := time.ParseDuration(value)
+			if err != nil || silence < 0 {
+				return fmt.Errorf("invalid leading_silence %q: use a duration such as 500ms, or 0s for none", value)
+			}
+			d.LeadingSilence = &silence
+		case "trailing_silence":
+			silence, err := time.ParseDuration(value)
+			if err != nil || silence < 0 {
+				return fmt.Errorf("invalid trailing_silence %q: use a duration such as 2s, or 0s for none", value)
+			}
+			d.TrailingSilence = &silence
+		case "silence":
+			silence, err := time.ParseDuration(value)
+			if err != nil || silence <= 0 {
+				return fmt.Errorf("invalid silence %q: use a positive duration such as 5s", value)
+			}
+			d.Silence = silence
+		default:
+			return fmt.Errorf("unknown directive %q (available: voice, rate, leading_silence, trailing_silence, silence)", key)
+		}
+	}
+	return nil
+}
+
+// applyTo returns opts with the slide's overrides applied
+func (d slideDirectives) applyTo(opts ttsOptions) ttsOptions {
+	if d.Voice != "" {
+		opts.Voice = d.Voice
+	}
+	if d.Rate > 0 {
+		opts.Speed = d.Rate
+		// Gemini has no rate parameter, so pacing is asked for in the prompt instead
+		pace := fmt.Sprintf("at about %d%% of normal speaking speed", int(d.Rate*100))
+		if opts.Style != "" {
+			opts.Style += ", " + pace
+		} else {
+			opts.Style = "Read this " + pace
+		}
+	}
+	if d.LeadingSilence != nil {
+		opts.LeadingSilence = *d.LeadingSilence
+	}
+	if d.TrailingSilence != nil {
+		opts.Silence = *d.TrailingSilence
+	}
+	return opts
+}
@@ -0,0 +1,85 @@
+package parfait
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/runenames"
+)
+
+// Emoji policies: backends range from reading emoji aloud to choking on them
+const (
+	emojiPolicyKeep  = "keep"
+	emojiPolicyStrip = "strip"
+	emojiPolicyName  = "name"
+)
+
+var emojiPolicies = []string{emojiPolicyKeep, emojiPolicyStrip, emojiPolicyName}
+
+func validateEmojiPolicy(policy string) error {
+	if policy != "" && !slices.Contains(emojiPolicies, policy) {
+		return fmt.Errorf("invalid emoji policy: %q. Use %s", policy, strings.Join(emojiPolicies, ", "))
+	}
+	return nil
+}
+
+// isEmoji reports runes that start an emoji
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, flags, supplemental symbols
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols and dingbats
+		return unicode.Is(unicode.So, r)
+	case r == 0x2B50 || r == 0x2B55 || r == 0x2B1B || r == 0x2B1C || r == 0x231A || r == 0x231B:
+		return true
+	}
+	return false
+}
+
+// isEmojiJoiner reports runes that continue an emoji sequence (ZWJ, variation selectors,
+// skin tones, keycaps, tags)
+func isEmojiJoiner(r rune) bool {
+	return r == 0x200D || r == 0xFE0F || r == 0xFE0E || r == 0x20E3 ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0xE0020 && r <= 0xE007F)
+}
+
+// applyEmojiPolicy strips emoji or replaces them with their names ("" = keep).
+// Names come from Unicode and are English, so other languages strip them instead.
+func applyEmojiPolicy(text, policy, lang string) string {
+	if policy == "" || policy == emojiPolicyKeep {
+		return text
+	}
+	named := policy == emojiPolicyName && baseLanguage(lang) == "en"
+
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if !isEmoji(r) {
+			b.WriteRune(r)
+			continue
+		}
+		// Consume the whole sequence (👩‍💻, 👍🏽, 🇯🇵) and name it after its first rune
+		j := i + 1
+		for j < len(runes) && (isEmojiJoiner(runes[j]) || (runes[j-1] == 0x200D && isEmoji(runes[j])) ||
+			(r >= 0x1F1E6 && r <= 0x1F1FF && j == i+1 && runes[j] >= 0x1F1E6 && runes[j] <= 0x1F1FF)) {
+			j++
+		}
+		if named {
+			name := runenames.Name(r)
+			if r >= 0x1F1E6 && r <= 0x1F1FF {
+				name = "flag"
+			}
+			if name != "" {
+				b.WriteString(" " + strings.ToLower(name) + " emoji ")
+			}
+		}
+		i = j - 1
+	}
+	if named {
+		return strings.Join(strings.Fields(b.String()), " ")
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package parfait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"google.golang.org/genai"
+)
+
+// errorClass describes how a provider failure should be handled
+type errorClass int
+
+const (
+	// errorFatal failures will fail again with the same input (bad request, unsupported voice, ...)
+	errorFatal errorClass = iota
+	// errorRateLimited is HTTP 429 / quota exhaustion
+	errorRateLimited
+	// errorServer is a 5xx response from the provider
+	errorServer
+	// errorNetwork covers transport failures and client-side timeouts
+	errorNetwork
+	// errorAuth is a rejected credential (401/403); another key may still work
+	errorAuth
+	// errorCanceled means the run itself was canceled
+	errorCanceled
+)
+
+func (c errorClass) String() string {
+	switch c {
+	case errorRateLimited:
+		return "rate-limited"
+	case errorServer:
+		return "server"
+	case errorNetwork:
+		return "network"
+	case errorAuth:
+		return "auth"
+	case errorCanceled:
+		return "canceled"
+	default:
+		return "fatal"
+	}
+}
+
+// retryable reports whether retrying the same request may succeed
+func (c errorClass) retryable() bool {
+	return c == errorRateLimited || c == errorServer || c == errorNetwork
+}
+
+// httpStatusError is returned when a provider answers with a non-200 status
+type httpStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("TTS API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// classifyError inspects typed provider errors (genai.APIError, httpStatusError,
+// net.Error) instead of matching substrings of the message.
+func classifyError(err error) errorClass {
+	if errors.Is(err, context.Canceled) {
+		return errorCanceled
+	}
+
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return classifyStatus(apiErr.Code)
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) {
+		return classifyStatus(apiErrPtr.Code)
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return classifyStatus(statusErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return errorNetwork
+	}
+	return errorFatal
+}
+
+func classifyStatus(code int) errorClass {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return errorRateLimited
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return errorAuth
+	case code == http.StatusRequestTimeout:
+		return errorNetwork
+	case code >= 500:
+		return errorServer
+	default:
+		return errorFatal
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package parfait
+
+import "os/exec"
+
+// setStageCancel kills an exec stage's shell on cancellation; process groups and
+// SIGTERM are only available on Unix
+func setStageCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error { return cmd.Process.Kill() }
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package parfait
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setStageCancel runs an exec stage in its own process group, so that cancelling it
+// signals the shell's children (ffmpeg and the like) too and not only the shell
+func setStageCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	// Ask the group to stop first; WaitDelay kills the shell if it doesn't exit in time
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM) }
+}
@@ -0,0 +1,31 @@
+package parfait
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// checkFFmpeg fails early when a feature needs ffmpeg and it is not installed
+func checkFFmpeg(feature string) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("%s needs ffmpeg, which was not found in PATH", feature)
+	}
+	return nil
+}
+
+// runFFmpeg runs ffmpeg quietly, overwriting outputs; its error output is returned on failure
+func runFFmpeg(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", append([]string{"-y", "-hide_banner", "-loglevel", "error"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %s", msg)
+		}
+		return fmt.Errorf("ffmpeg: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package parfait
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-audio/audio"
+)
+
+// Values of --denoise
+const (
+	denoiseFFT     = "fft"     // ffmpeg afftdn: spectral noise reduction, no model needed
+	denoiseRNNoise = "rnnoise" // ffmpeg arnndn: neural noise reduction with an .rnnn model
+)
+
+var denoiseMethods = []string{denoiseFFT, denoiseRNNoise}
+
+// denoiseOptions selects the noise reduction applied to synthesized slides
+type denoiseOptions struct {
+	Method string // "" = off
+	Model  string // RNNoise model file for denoiseRNNoise
+}
+
+func (d denoiseOptions) validate() error {
+	switch d.Method {
+	case "":
+		return nil
+	case denoiseFFT:
+	case denoiseRNNoise:
+		if d.Model == "" {
+			return fmt.Errorf("--denoise rnnoise needs --denoise-model <file.rnnn>")
+		}
+		if _, err := os.Stat(d.Model); err != nil {
+			return fmt.Errorf("denoise model: %v", err)
+		}
+	default:
+		return fmt.Errorf("invalid denoise method: %q. Use %s", d.Method, strings.Join(denoiseMethods, ", "))
+	}
+	return checkFFmpeg("--denoise")
+}
+
+// filter is the ffmpeg audio filter for the method ("" = off)
+func (d denoiseOptions) filter() string {
+	switch d.Method {
+	case denoiseFFT:
+		return "afftdn=nf=-25"
+	case denoiseRNNoise:
+		return "arnndn=m=" + escapeFilterValue(d.Model)
+	}
+	return ""
+}
+
+// escapeFilterValue quotes a filter option value, such as a Windows path, for -af
+func escapeFilterValue(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// slideFilters is the ffmpeg filter chain applied to each synthesized slide ("" = none):
+// noise reduction first, then the user's --audio-filters
+func (o ttsOptions) slideFilters() string {
+	var chain []string
+	for _, f := range []string{o.Denoise.filter(), strings.TrimSpace(o.AudioFilters)} {
+		if f != "" {
+			chain = append(chain, f)
+		}
+	}
+	return strings.Join(chain, ",")
+}
+
+// filterAudio runs buf through an ffmpeg -af filter chain, keeping its bit depth
+func filterAudio(ctx context.Context, buf *audio.IntBuffer, filters string) (*audio.IntBuffer, error) {
+	dir, err := os.MkdirTemp("", "parfait-filter-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.wav")
+	out := filepath.Join(dir, "out.wav")
+	if err := writeWAVBuffer(in, buf, nil); err != nil {
+		return nil, err
+	}
+	if err := runFFmpeg(ctx, "-i", in, "-af", filters, "-codec:a", pcmCodec(buf.SourceBitDepth), out); err != nil {
+		return nil, fmt.Errorf("audio filters failed: %v", err)
+	}
+	filtered, err := readSlideWAV(out)
+	if err != nil {
+		return nil, fmt.Errorf("audio filters failed: %v", err)
+	}
+	return filtered, nil
+}
+
+// pcmCodec is the ffmpeg WAV codec that keeps a bit depth
+func pcmCodec(bitDepth int) string {
+	if bitDepth == 8 {
+		return "pcm_u8"
+	}
+	return fmt.Sprintf("pcm_s%dle", bitDepth)
+}
@@ -0,0 +1,40 @@
+package parfait
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Reading annotations in notes. The reading is sent to the provider while the
+// transcript keeps only the base text.
+//
+//	漢字｛かんじ｝            inline reading after a run of kanji
+//	漢字《かんじ》 ｜東京《とうきょう》  Aozora Bunko ruby (｜ marks where the base starts)
+//	{漢字|かんじ}            DenDenMarkdown ruby
+//	<ruby>漢字<rt>かんじ</rt></ruby>  HTML ruby
+var rubyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?s)<ruby>(.*?)(?:<rp>.*?</rp>)?<rt>(.*?)</rt>(?:<rp>.*?</rp>)?</ruby>`),
+	regexp.MustCompile(`\{([^{}|]+)\|([^{}|]+)\}`),
+	regexp.MustCompile(`[｜|]([^｜|《》\s]+)《([^《》]+)》`),
+	regexp.MustCompile(`([\p{Han}々〆ヶ]+)[《｛]([^《》｛｝]+)[》｝]`),
+}
+
+// rubyReading replaces annotated text with its reading
+func rubyReading(text string) string {
+	return replaceRuby(text, "$2")
+}
+
+// rubyBase drops reading annotations, keeping the annotated text
+func rubyBase(text string) string {
+	return replaceRuby(text, "$1")
+}
+
+func replaceRuby(text, template string) string {
+	if !strings.ContainsAny(text, "《｛{<") {
+		return text
+	}
+	for _, p := range rubyPatterns {
+		text = p.ReplaceAllString(text, template)
+	}
+	return text
+}
@@ -0,0 +1,75 @@
+package parfait
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	defaultTTSTimeout    = 30 * time.Second
+	defaultHealthTimeout = 5 * time.Second
+
+	// Env defaults for the timeouts (see `config set timeouts.tts` / `timeouts.health`)
+	ttsTimeoutEnv    = "PARFAIT_TTS_TIMEOUT"
+	healthTimeoutEnv = "PARFAIT_HEALTH_TIMEOUT"
+)
+
+// resolveTimeout picks the flag value if set, then the env / global config value, then def
+func resolveTimeout(flagVal time.Duration, env string, def time.Duration) (time.Duration, error) {
+	if flagVal < 0 {
+		return 0, fmt.Errorf("timeout must not be negative: %s", flagVal)
+	}
+	if flagVal > 0 {
+		return flagVal, nil
+	}
+	if v := os.Getenv(env); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid %s: %q", env, v)
+		}
+		return d, nil
+	}
+	return def, nil
+}
+
+// validateProxyURL checks a --proxy value before any request is made
+func validateProxyURL(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL: %s", redact(proxyURL))
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return nil
+	}
+	return fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", u.Scheme)
+}
+
+// newHTTPClient builds a client for provider requests. It honors HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY; a non-empty proxyURL replaces the proxy for both
+// schemes while NO_PROXY still applies.
+func newHTTPClient(timeout time.Duration, proxyURL string) *http.Client {
+	cfg := httpproxy.FromEnvironment()
+	if proxyURL != "" {
+		cfg.HTTPProxy = proxyURL
+		cfg.HTTPSProxy = proxyURL
+	}
+	proxyFunc := cfg.ProxyFunc()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,83 @@
+package parfait
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-audio/audio"
+)
+
+// validateJingle checks an --intro or --outro file; formats other than WAV need ffmpeg
+func validateJingle(flag, path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("--%s: %v", flag, err)
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".wav") {
+		return checkFFmpeg("--" + flag + " " + filepath.Ext(path))
+	}
+	return nil
+}
+
+// loadJingle reads an intro or outro clip and converts it to the sample rate, channels
+// and bit depth of like, so it can be joined with the narration
+func loadJingle(ctx context.Context, path string, like *audio.IntBuffer) (*audio.IntBuffer, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".wav") {
+		return decodeWithFFmpeg(ctx, path, like)
+	}
+	clip, err := readSlideWAV(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	clip = convertChannels(resample(clip, like.Format.SampleRate), like.Format.NumChannels)
+	convertBitDepth(clip, like.SourceBitDepth)
+	return clip, nil
+}
+
+// decodeWithFFmpeg decodes any audio file ffmpeg reads into the format of like
+func decodeWithFFmpeg(ctx context.Context, path string, like *audio.IntBuffer) (*audio.IntBuffer, error) {
+	dir, err := os.MkdirTemp("", "parfait-jingle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "clip.wav")
+	err = runFFmpeg(ctx, "-i", path, "-vn",
+		"-ar", strconv.Itoa(like.Format.SampleRate), "-ac", strconv.Itoa(like.Format.NumChannels),
+		"-codec:a", pcmCodec(like.SourceBitDepth), out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return readSlideWAV(out)
+}
+
+// addJingles puts the intro before and the outro after the deck narration and returns
+// how far the intro moves the slides
+func addJingles(ctx context.Context, buf *audio.IntBuffer, opts ttsOptions) (*audio.IntBuffer, time.Duration, error) {
+	parts := []audioPart{{clip: buf}}
+	var offset time.Duration
+	if opts.Intro != "" {
+		intro, err := loadJingle(ctx, opts.Intro, buf)
+		if err != nil {
+			return nil, 0, fmt.Errorf("intro: %v", err)
+		}
+		parts = append([]audioPart{{clip: intro}}, parts...)
+		offset = wavDuration(intro)
+	}
+	if opts.Outro != "" {
+		outro, err := loadJingle(ctx, opts.Outro, buf)
+		if err != nil {
+			return nil, 0, fmt.Errorf("outro: %v", err)
+		}
+		parts = append(parts, audioPart{clip: outro})
+	}
+	joined, err := joinAudio(parts)
+	return joined, offset, err
+}
@@ -0,0 +1,71 @@
+package parfait
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// geminiLanguages are the primary language subtags Gemini TTS documents support for.
+// Gemini detects the language from the text itself, so the tag is only validated.
+var geminiLanguages = []string{
+	"ar", "bn", "de", "en", "es", "fr", "hi", "id", "it", "ja", "ko", "mr",
+	"nl", "pl", "pt", "ro", "ru", "ta", "te", "th", "tr", "uk", "vi",
+}
+
+// kokoVoxDefaultLanguages is assumed when the KokoVox server has no /info endpoint
+var kokoVoxDefaultLanguages = []string{"ja", "en"}
+
+// normalizeLanguage parses a BCP-47 tag ("ja", "en-US", "pt_br") into its canonical form
+func normalizeLanguage(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", fmt.Errorf("language is empty")
+	}
+	t, err := language.Parse(tag)
+	if err != nil {
+		return "", fmt.Errorf("invalid language tag %q: %v", tag, err)
+	}
+	return t.String(), nil
+}
+
+// baseLanguage returns the primary language subtag ("en" for "en-US")
+func baseLanguage(tag string) string {
+	t, err := language.Parse(tag)
+	if err != nil {
+		return strings.ToLower(tag)
+	}
+	b, _ := t.Base()
+	return b.String()
+}
+
+// languageSupported reports whether tag matches one of supported, either exactly
+// or by primary language ("en-GB" is covered by "en")
+func languageSupported(tag string, supported []string) bool {
+	base := baseLanguage(tag)
+	for _, s := range supported {
+		if strings.EqualFold(s, tag) || baseLanguage(s) == base {
+			return true
+		}
+	}
+	return false
+}
+
+// validateGeminiLanguage checks a tag against Gemini's supported languages
+func validateGeminiLanguage(tag string) error {
+	if !languageSupported(tag, geminiLanguages) {
+		return fmt.Errorf("gemini does not support language %q (supported: %s)", tag, strings.Join(geminiLanguages, ", "))
+	}
+	return nil
+}
+
+// kokoVoxLanguage is the value sent to KokoVox, which expects a primary language subtag
+func kokoVoxLanguage(tag string) string {
+	return baseLanguage(tag)
+}
+
+// isLanguage reports whether tag has the given primary language
+func isLanguage(tag, base string) bool {
+	return baseLanguage(tag) == base
+}
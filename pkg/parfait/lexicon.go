@@ -0,0 +1,143 @@
+package parfait
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lexiconAllLanguages is the lexicon section applied regardless of language
+const lexiconAllLanguages = "*"
+
+// lexicon rewrites terms to their readings before synthesis.
+// The file maps language tags to term → reading tables (YAML or JSON):
+//
+//	"*":
+//	  k8s: kubernetes
+//	ja:
+//	  k8s: クバネティス
+//	  生成AI: せいせいエーアイ
+//
+// Sections for the primary language ("en") and the full tag ("en-GB") both apply,
+// the more specific one winning.
+type lexicon struct {
+	pattern  *regexp.Regexp
+	readings map[string]string
+}
+
+// loadLexicon reads the lexicon file and keeps the entries for lang
+func loadLexicon(path, lang string) (*lexicon, error) {
+	readings, err := readLexiconFile(path, lang)
+	if err != nil {
+		return nil, err
+	}
+	return newLexicon(readings), nil
+}
+
+// readLexiconFile returns the term → reading entries of a lexicon file that apply to lang
+func readLexiconFile(path, lang string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lexicon: %v", err)
+	}
+	var sections map[string]map[string]string
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	if err := dec.Decode(&sections); err != nil {
+		return nil, fmt.Errorf("invalid lexicon (%s): %v", path, err)
+	}
+
+	// Apply "*", then the primary language, then the full tag so the most specific wins
+	var all, base, exact map[string]string
+	for k, entries := range sections {
+		if k == lexiconAllLanguages {
+			all = entries
+			continue
+		}
+		tag, err := normalizeLanguage(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lexicon (%s): %w", path, err)
+		}
+		switch {
+		case tag == lang:
+			exact = entries
+		case tag == baseLanguage(lang):
+			base = entries
+		}
+	}
+
+	readings := make(map[string]string)
+	for _, entries := range []map[string]string{all, base, exact} {
+		for term, reading := range entries {
+			if term = strings.TrimSpace(term); term != "" {
+				readings[term] = reading
+			}
+		}
+	}
+	return readings, nil
+}
+
+// newLexicon compiles the readings; nil means no rewriting
+func newLexicon(readings map[string]string) *lexicon {
+	if len(readings) == 0 {
+		return nil
+	}
+	terms := make([]string, 0, len(readings))
+	for term := range readings {
+		terms = append(terms, term)
+	}
+	// Longest first so "k8s cluster" wins over "k8s"
+	slices.SortFunc(terms, func(a, b string) int {
+		if d := len(b) - len(a); d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+
+	alts := make([]string, len(terms))
+	for i, term := range terms {
+		alts[i] = regexp.QuoteMeta(term)
+		// Latin terms only match whole words ("AI" must not rewrite "MAIL"); CJK has no word breaks
+		if isWordChar(firstRune(term)) {
+			alts[i] = `\b` + alts[i]
+		}
+		if isWordChar(lastRune(term)) {
+			alts[i] += `\b`
+		}
+	}
+	return &lexicon{
+		pattern:  regexp.MustCompile(strings.Join(alts, "|")),
+		readings: readings,
+	}
+}
+
+// apply replaces every known term in text with its reading
+func (l *lexicon) apply(text string) string {
+	if l == nil {
+		return text
+	}
+	return l.pattern.ReplaceAllStringFunc(text, func(term string) string {
+		return l.readings[term]
+	})
+}
+
+func isWordChar(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+func lastRune(s string) rune {
+	r := []rune(s)
+	return r[len(r)-1]
+}
@@ -0,0 +1,172 @@
+package parfait
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultSpeechRates are typical narration speeds in characters per second, by primary language
+var defaultSpeechRates = map[string]float64{
+	"ja": 7,
+	"zh": 5,
+	"ko": 7,
+	"en": 15,
+}
+
+// fallbackSpeechRate applies to languages without a default rate
+const fallbackSpeechRate = 13.0
+
+// parseSpeechRates parses "ja=7,en=15" overrides on top of defaultSpeechRates
+func parseSpeechRates(s string) (map[string]float64, error) {
+	rates := maps.Clone(defaultSpeechRates)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		lang, value, ok := strings.Cut(pair, "=")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid speech rate %q: use lang=chars-per-second, e.g. ja=7", pair)
+		}
+		rates[baseLanguage(strings.TrimSpace(lang))] = rate
+	}
+	return rates, nil
+}
+
+// estimateSpeech estimates how long a slide takes to narrate, including pauses
+func estimateSpeech(note SlideNote, lang string, rates map[string]float64, segmentPause time.Duration) time.Duration {
+	if note.Directives.Silence > 0 {
+		return note.Directives.Silence
+	}
+	rate, ok := rates[baseLanguage(lang)]
+	if !ok {
+		rate = fallbackSpeechRate
+	}
+	if note.Directives.Rate > 0 {
+		rate *= note.Directives.Rate
+	}
+
+	var d time.Duration
+	for i, segment := range note.segments() {
+		if i > 0 {
+			d += segmentPause
+		}
+		parts, _ := splitPauses(segment)
+		for _, part := range parts {
+			d += part.pause
+			chars := len([]rune(strings.Join(strings.Fields(part.text), "")))
+			d += time.Duration(float64(chars) / rate * float64(time.Second))
+		}
+	}
+	return d
+}
+
+// lintNotes returns a warning for every slide whose estimated narration exceeds budget
+func lintNotes(notes []SlideNote, lang string, budget time.Duration, rates map[string]float64, segmentPause time.Duration) []string {
+	if budget <= 0 {
+		return nil
+	}
+	var warnings []string
+	for _, note := range notes {
+		if d := estimateSpeech(note, lang, rates, segmentPause); d > budget {
+			warnings = append(warnings, fmt.Sprintf("slide %03d: notes take about %s to read, over the %s budget", note.SlideNumber, d.Round(time.Second), budget))
+		}
+	}
+	return warnings
+}
+
+var (
+	validateLangFlag        string
+	validateSlideBudgetFlag time.Duration
+	validateRatesFlag       string
+	validateFallbackFlag    string
+	validateFormatFlag      string
+	validateSkipFlag        string
+	validateDelimiterFlag   string
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <deck-file|url>",
+	Short: "Check a deck's notes without generating audio",
+	Long: `Parse a deck the way a run would and report problems: slides without notes,
+invalid directives or pause markers, and notes too long for --slide-budget.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deckFile := args[0]
+		if isRemoteDeck(deckFile) {
+			local, cleanup, err := downloadDeck(cmd.Context(), deckFile, "")
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			deckFile = local
+		}
+		content, err := os.ReadFile(deckFile)
+		if err != nil {
+			return fmt.Errorf("failed to read markdown file: %v", err)
+		}
+		deck, err := loadDeckConfig(content)
+		if err != nil {
+			return err
+		}
+		lang, err := normalizeLanguage(cmp.Or(validateLangFlag, deck.Language, "en"))
+		if err != nil {
+			return err
+		}
+		rates, err := parseSpeechRates(validateRatesFlag)
+		if err != nil {
+			return err
+		}
+		notesOpts := notesOptions{
+			Fallback:     validateFallbackFlag,
+			Format:       deckFormatForFile(deckFile, validateFormatFlag),
+			SkipComments: validateSkipFlag,
+			Delimiter:    cmp.Or(validateDelimiterFlag, deck.Delimiter),
+		}
+		if err := notesOpts.validate(); err != nil {
+			return err
+		}
+
+		notes, err := extractNotesFromMarkdown(content, notesOpts)
+		if err != nil {
+			return err
+		}
+
+		pause := defaultSegmentPause
+		if d, _ := deck.segmentPause(); d >= 0 {
+			pause = d
+		}
+		var total time.Duration
+		for _, note := range notes {
+			total += estimateSpeech(note, lang, rates, pause)
+		}
+		fmt.Printf("%d slides, about %s of narration\n", len(notes), total.Round(time.Second))
+
+		warnings := lintNotes(notes, lang, validateSlideBudgetFlag, rates, pause)
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		if len(warnings) > 0 {
+			return fmt.Errorf("%d slides exceed the %s budget", len(warnings), validateSlideBudgetFlag)
+		}
+		fmt.Println("✓ Deck is valid")
+		return nil
+	},
+}
+
+func init() {
+	validateCmd.Flags().StringVarP(&validateLangFlag, "lang", "l", "", "Language of the notes (default: parfait.language in the frontmatter, else en)")
+	validateCmd.Flags().DurationVar(&validateSlideBudgetFlag, "slide-budget", 0, "Flag slides whose notes take longer than this to read (0 = no limit)")
+	validateCmd.Flags().StringVar(&validateRatesFlag, "chars-per-second", "", "Speech rate overrides per language, e.g. ja=7,en=15")
+	validateCmd.Flags().StringVar(&validateFallbackFlag, "notes-fallback", notesFallbackError, "What to do with slides without a comment: error|body")
+	validateCmd.Flags().StringVar(&validateFormatFlag, "deck-format", deckFormatAuto, "Deck dialect: "+strings.Join(deckFormats, "|"))
+	validateCmd.Flags().StringVar(&validateSkipFlag, "skip-comments", "", "Regular expression for comments that are not narration")
+	validateCmd.Flags().StringVar(&validateDelimiterFlag, "delimiter", "", "Only split Marp slides at this line: ---, ***, ___ or a comment such as \"<!-- slide -->\"")
+}
@@ -0,0 +1,144 @@
+package parfait
+
+import (
+	"math"
+
+	"github.com/go-audio/audio"
+)
+
+// minLoudnessTarget is the quietest --loudness target accepted (the BS.1770 absolute gate)
+const minLoudnessTarget = -70
+
+// Peak ceiling after loudness normalization (-1 dBFS), leaving headroom for encoders
+const loudnessPeakCeiling = 0.891
+
+// integratedLoudness measures buf in LUFS per EBU R128 / ITU-R BS.1770: K-weighted,
+// 400ms blocks with 75% overlap, absolute gate at -70 LUFS and relative gate at -10 LU.
+// ok is false when the audio is shorter than one block or entirely below the gate.
+func integratedLoudness(buf *audio.IntBuffer) (lufs float64, ok bool) {
+	channels := buf.Format.NumChannels
+	rate := buf.Format.SampleRate
+	frames := len(buf.Data) / channels
+	block := int(0.4 * float64(rate))
+	step := block / 4
+	if frames < block || step == 0 {
+		return 0, false
+	}
+
+	// K-weighted squares per channel
+	scale := 1 / float64(int(1)<<(buf.SourceBitDepth-1))
+	squares := make([][]float64, channels)
+	for ch := range squares {
+		shelf, pass := kWeightingFilters(float64(rate))
+		sq := make([]float64, frames)
+		for i := range frames {
+			y := pass.process(shelf.process(float64(buf.Data[i*channels+ch]) * scale))
+			sq[i] = y * y
+		}
+		squares[ch] = sq
+	}
+
+	var blocks []float64
+	for start := 0; start+block <= frames; start += step {
+		var z float64
+		for ch := range channels {
+			var sum float64
+			for _, v := range squares[ch][start : start+block] {
+				sum += v
+			}
+			z += sum / float64(block)
+		}
+		blocks = append(blocks, z)
+	}
+
+	loudness := func(z float64) float64 { return -0.691 + 10*math.Log10(z) }
+	gatedMean := func(threshold float64) (float64, bool) {
+		var sum float64
+		n := 0
+		for _, z := range blocks {
+			if z > 0 && loudness(z) > threshold {
+				sum += z
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, false
+		}
+		return sum / float64(n), true
+	}
+
+	mean, ok := gatedMean(-70)
+	if !ok {
+		return 0, false
+	}
+	mean, ok = gatedMean(loudness(mean) - 10)
+	if !ok {
+		return 0, false
+	}
+	return loudness(mean), true
+}
+
+// normalizeLoudness scales buf to the target LUFS, keeping peaks below loudnessPeakCeiling.
+// It returns the loudness reached, which is below the target when the peak limit applied.
+func normalizeLoudness(buf *audio.IntBuffer, target float64) (float64, bool) {
+	measured, ok := integratedLoudness(buf)
+	if !ok {
+		return 0, false
+	}
+	gain := dbToGain(target - measured)
+
+	peak := peakLevel(buf)
+	if peak > 0 && peak*gain > loudnessPeakCeiling {
+		gain = loudnessPeakCeiling / peak
+	}
+	applyGain(buf, gain)
+	return measured + 20*math.Log10(gain), true
+}
+
+// biquad is a second-order IIR filter (direct form I)
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeightingFilters returns the BS.1770 pre-filter (high shelf) and RLB high-pass
+// filter, designed for the sample rate so non-48kHz audio is weighted correctly
+func kWeightingFilters(rate float64) (*biquad, *biquad) {
+	// High shelf: +4 dB above ~1.7 kHz
+	g, q, fc := 3.99984385397, 0.7071752369554193, 1681.9744509555319
+	a := math.Pow(10, g/40)
+	w0 := 2 * math.Pi * fc / rate
+	alpha := math.Sin(w0) / (2 * q)
+	cosw := math.Cos(w0)
+	sqa := 2 * math.Sqrt(a) * alpha
+	a0 := (a + 1) - (a-1)*cosw + sqa
+	shelf := &biquad{
+		b0: a * ((a + 1) + (a-1)*cosw + sqa) / a0,
+		b1: -2 * a * ((a - 1) + (a+1)*cosw) / a0,
+		b2: a * ((a + 1) + (a-1)*cosw - sqa) / a0,
+		a1: 2 * ((a - 1) - (a+1)*cosw) / a0,
+		a2: ((a + 1) - (a-1)*cosw - sqa) / a0,
+	}
+
+	// High pass at ~38 Hz
+	q, fc = 0.5003270373253953, 38.13547087613982
+	w0 = 2 * math.Pi * fc / rate
+	alpha = math.Sin(w0) / (2 * q)
+	cosw = math.Cos(w0)
+	a0 = 1 + alpha
+	pass := &biquad{
+		b0: (1 + cosw) / 2 / a0,
+		b1: -(1 + cosw) / a0,
+		b2: (1 + cosw) / 2 / a0,
+		a1: -2 * cosw / a0,
+		a2: (1 - alpha) / a0,
+	}
+	return shelf, pass
+}
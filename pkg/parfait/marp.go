@@ -0,0 +1,35 @@
+package parfait
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// marpDirectives are the global and local Marp directives; a local directive may be
+// prefixed with "_" to apply to the current slide only
+var marpDirectives = map[string]bool{
+	// Global
+	"theme": true, "style": true, "headingDivider": true, "lang": true, "size": true,
+	"math": true, "title": true, "author": true, "description": true, "image": true,
+	"keywords": true, "url": true, "marp": true, "transition": true,
+	// Local
+	"paginate": true, "header": true, "footer": true, "class": true,
+	"backgroundColor": true, "backgroundImage": true, "backgroundPosition": true,
+	"backgroundRepeat": true, "backgroundSize": true, "color": true,
+}
+
+// isMarpDirective reports whether a comment only sets Marp directives,
+// e.g. "_class: lead" or "paginate: true"
+func isMarpDirective(comment string) bool {
+	var fields map[string]any
+	if err := yaml.Unmarshal([]byte(comment), &fields); err != nil || len(fields) == 0 {
+		return false
+	}
+	for k := range fields {
+		if !marpDirectives[strings.TrimPrefix(k, "_")] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package parfait
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// deckMeta is the deck's title, author and date from the frontmatter; Marp decks
+// already use these keys as global directives
+type deckMeta struct {
+	Title  string
+	Author string
+	Date   string
+	// Language is the narration language, taken from the run rather than the frontmatter
+	Language string
+}
+
+// metaValue formats a frontmatter value: dates as YYYY-MM-DD and lists joined with "; "
+func metaValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.DateOnly)
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, p := range v {
+			if s := metaValue(p); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, "; ")
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+// tagsSlides reports whether the frontmatter has metadata to tag slide files with. The
+// language alone does not justify re-encoding provider WAV files that are otherwise
+// saved as-is, so it is only added when slides are tagged anyway.
+func (m deckMeta) tagsSlides() bool {
+	return m.Title != "" || m.Author != "" || m.Date != ""
+}
+
+// wavMetadata tags a slide's WAV file: the deck title is the album, the slide the track
+func (m deckMeta) wavMetadata(note SlideNote) riffInfo {
+	return riffInfo{
+		{"INAM", note.displayTitle()},
+		{"IPRD", m.Title},
+		{"IART", m.Author},
+		{"ICRD", m.Date},
+		{"ITRK", strconv.Itoa(note.SlideNumber)},
+		{"ILNG", m.Language},
+		{"ISFT", "parfait"},
+	}
+}
+
+// riffInfo holds the entries of a RIFF LIST-INFO chunk as {id, value} pairs
+type riffInfo [][2]string
+
+// chunk encodes the LIST-INFO chunk; empty values are left out and each value is
+// NUL-terminated and padded to an even size as RIFF requires
+func (info riffInfo) chunk() []byte {
+	var b bytes.Buffer
+	b.WriteString("INFO")
+	for _, tag := range info {
+		if tag[1] == "" {
+			continue
+		}
+		value := append([]byte(tag[1]), 0)
+		b.WriteString(tag[0])
+		binary.Write(&b, binary.LittleEndian, uint32(len(value)))
+		b.Write(value)
+		if len(value)%2 == 1 {
+			b.WriteByte(0)
+		}
+	}
+	var out bytes.Buffer
+	out.WriteString("LIST")
+	binary.Write(&out, binary.LittleEndian, uint32(b.Len()))
+	out.Write(b.Bytes())
+	return out.Bytes()
+}
+
+// slugify keeps letters and digits (any script) and joins the rest with single hyphens
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package parfait
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// slideNameData is what a template output pattern such as
+// "{{.Lang}}-{{.Slide}}-{{.TitleSlug}}.wav" can refer to
+type slideNameData struct {
+	Slide     string // zero-padded file number ("001")
+	Number    int    // file number: the slide number shifted by --number-start
+	Lang      string
+	Title     string
+	TitleSlug string
+	// Deck, Author and Date are slugs of the frontmatter title, author and date
+	Deck   string
+	Author string
+	Date   string
+}
+
+// isTemplatePattern reports whether an output pattern uses text/template syntax
+// rather than a printf verb
+func isTemplatePattern(pattern string) bool {
+	return strings.Contains(pattern, "{{")
+}
+
+// parseNameTemplate parses a template output pattern; missing fields are errors
+func parseNameTemplate(pattern string) (*template.Template, error) {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output pattern %q: %v", pattern, err)
+	}
+	return tmpl, nil
+}
+
+// defaultNumberPadding is the width slide numbers are zero-padded to in file names
+const defaultNumberPadding = 3
+
+// fileNumber is the number a slide's file is named with
+func fileNumber(slideNum int, opts ttsOptions) int {
+	return slideNum + opts.NumberOffset
+}
+
+// paddedNumber zero-pads a file number to opts.NumberPadding digits
+func paddedNumber(n int, opts ttsOptions) string {
+	return fmt.Sprintf("%0*d", cmp.Or(opts.NumberPadding, defaultNumberPadding), n)
+}
+
+// slideOutputPath returns the WAV path for a slide: its rendered name when the output
+// pattern is a template, else the printf pattern (default: the padded number + ".wav")
+func slideOutputPath(outputDir string, slideNum int, opts ttsOptions) string {
+	if name, ok := opts.slideNames[slideNum]; ok {
+		return filepath.Join(outputDir, name)
+	}
+	n := fileNumber(slideNum, opts)
+	if opts.OutputPattern == "" || isTemplatePattern(opts.OutputPattern) {
+		return filepath.Join(outputDir, paddedNumber(n, opts)+".wav")
+	}
+	return filepath.Join(outputDir, fmt.Sprintf(opts.OutputPattern, n))
+}
+
+// renderSlideName fills a template output pattern for one slide
+func renderSlideName(tmpl *template.Template, note SlideNote, opts ttsOptions) (string, error) {
+	n := fileNumber(note.SlideNumber, opts)
+	meta := opts.Meta
+	var b bytes.Buffer
+	err := tmpl.Execute(&b, slideNameData{
+		Slide:     paddedNumber(n, opts),
+		Number:    n,
+		Lang:      opts.Language,
+		Title:     note.Title,
+		TitleSlug: slugify(note.Title),
+		Deck:      slugify(meta.Title),
+		Author:    slugify(meta.Author),
+		Date:      slugify(meta.Date),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render output pattern: %v", err)
+	}
+	return b.String(), nil
+}
+
+// validateTemplatePattern checks a template output pattern against a sample slide
+func validateTemplatePattern(pattern string) error {
+	tmpl, err := parseNameTemplate(pattern)
+	if err != nil {
+		return err
+	}
+	name, err := renderSlideName(tmpl, SlideNote{SlideNumber: 1, Title: "Title"}, ttsOptions{Language: "en"})
+	if err != nil {
+		return err
+	}
+	return checkSlideName(pattern, name)
+}
+
+// checkSlideName rejects names that are paths or not WAV files
+func checkSlideName(pattern, name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid output pattern %q: must be a file name, not a path", pattern)
+	}
+	if !strings.EqualFold(filepath.Ext(name), ".wav") {
+		return fmt.Errorf("invalid output pattern %q: must end in .wav", pattern)
+	}
+	return nil
+}
+
+// slideNames renders a template output pattern for every slide. Two slides may not
+// share a name, so the template should include {{.Slide}} or {{.Number}}.
+func slideNames(notes []SlideNote, opts ttsOptions) (map[int]string, error) {
+	if !isTemplatePattern(opts.OutputPattern) {
+		return nil, nil
+	}
+	tmpl, err := parseNameTemplate(opts.OutputPattern)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(notes))
+	owner := make(map[string]int, len(notes))
+	for _, note := range notes {
+		name, err := renderSlideName(tmpl, note, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSlideName(opts.OutputPattern, name); err != nil {
+			return nil, err
+		}
+		if prev, ok := owner[strings.ToLower(name)]; ok {
+			return nil, fmt.Errorf("output pattern %q gives slides %03d and %03d the same name %s", opts.OutputPattern, prev, note.SlideNumber, name)
+		}
+		owner[strings.ToLower(name)] = note.SlideNumber
+		names[note.SlideNumber] = name
+	}
+	return names, nil
+}
@@ -0,0 +1,82 @@
+package parfait
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Number, date and unit normalization. TTS engines read "3.5GB", "2024-06-01" or
+// "v1.2.3" inconsistently, so they are expanded to words for the languages below;
+// other languages are passed through unchanged.
+
+var (
+	isoDatePattern = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	versionPattern = regexp.MustCompile(`\b[vV](\d+(?:\.\d+)+)\b`)
+	percentPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s?%`)
+	unitPattern    = regexp.MustCompile(`\b(\d+(?:\.\d+)?)\s?(TB|GB|MB|KB|kB|GHz|MHz|kHz|Hz|ms|km|kg|cm|mm)\b`)
+)
+
+// unitWords are the spoken unit names per primary language (English singular form)
+var unitWords = map[string]map[string]string{
+	"en": {
+		"TB": "terabyte", "GB": "gigabyte", "MB": "megabyte", "KB": "kilobyte", "kB": "kilobyte",
+		"GHz": "gigahertz", "MHz": "megahertz", "kHz": "kilohertz", "Hz": "hertz",
+		"ms": "millisecond", "km": "kilometer", "kg": "kilogram", "cm": "centimeter", "mm": "millimeter",
+	},
+	"ja": {
+		"TB": "テラバイト", "GB": "ギガバイト", "MB": "メガバイト", "KB": "キロバイト", "kB": "キロバイト",
+		"GHz": "ギガヘルツ", "MHz": "メガヘルツ", "kHz": "キロヘルツ", "Hz": "ヘルツ",
+		"ms": "ミリ秒", "km": "キロメートル", "kg": "キログラム", "cm": "センチメートル", "mm": "ミリメートル",
+	},
+}
+
+// normalizeText expands dates, versions, percentages and units into speakable words
+func normalizeText(text, lang string) string {
+	base := baseLanguage(lang)
+	units, ok := unitWords[base]
+	if !ok {
+		return text
+	}
+
+	text = isoDatePattern.ReplaceAllStringFunc(text, func(m string) string {
+		d, err := time.Parse("2006-01-02", m)
+		if err != nil {
+			return m
+		}
+		if base == "ja" {
+			return fmt.Sprintf("%d年%d月%d日", d.Year(), d.Month(), d.Day())
+		}
+		return d.Format("January 2, 2006")
+	})
+
+	text = versionPattern.ReplaceAllStringFunc(text, func(m string) string {
+		parts := strings.Split(m[1:], ".")
+		if base == "ja" {
+			return "バージョン" + strings.Join(parts, "点")
+		}
+		return "version " + strings.Join(parts, " point ")
+	})
+
+	text = percentPattern.ReplaceAllStringFunc(text, func(m string) string {
+		n := percentPattern.FindStringSubmatch(m)[1]
+		if base == "ja" {
+			return n + "パーセント"
+		}
+		return n + " percent"
+	})
+
+	return unitPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := unitPattern.FindStringSubmatch(m)
+		n, word := sub[1], units[sub[2]]
+		if base == "ja" {
+			return n + word
+		}
+		if v, err := strconv.ParseFloat(n, 64); (err != nil || v != 1) && !strings.HasSuffix(word, "hertz") {
+			word += "s"
+		}
+		return n + " " + word
+	})
+}
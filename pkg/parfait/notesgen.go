@@ -0,0 +1,258 @@
+package parfait
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"google.golang.org/genai"
+)
+
+// notesModel writes speaker notes for slides that have none
+const notesModel = "gemini-2.5-flash"
+
+// generatedNoteChars is a generous size of one generated note (up to 4 sentences), used
+// to check the budget before any note is generated
+const generatedNoteChars = 600
+
+var trailingBreakPattern = regexp.MustCompile(`\n[ \t]*([-*_])[ \t]*(?:[-*_][ \t]*){2,}\s*$`)
+
+// missingNote is a slide without narration and where to insert it
+type missingNote struct {
+	slide    int
+	title    string
+	markdown string
+	// offset is where the generated comment is inserted: the start of the slide's first block
+	offset int
+}
+
+// estimateWithGeneratedNotes estimates the run as if every one of the missing slides had
+// a generated note of generatedNoteChars
+func estimateWithGeneratedNotes(content []byte, opts notesOptions, missing int) (runEstimate, error) {
+	slides, _, err := parseDeck(content, opts)
+	if err != nil {
+		return runEstimate{}, err
+	}
+	est := runEstimate{Slides: missing, Chars: missing * generatedNoteChars}
+	for _, slide := range slides {
+		if len(slide.comments) == 0 {
+			continue
+		}
+		est.Slides++
+		for _, comment := range slide.comments {
+			est.Chars += len([]rune(stripPauseMarkers(rubyBase(comment))))
+		}
+	}
+	return est, nil
+}
+
+// findMissingNotes lists the slides of a deck that have no comment
+func findMissingNotes(content []byte, opts notesOptions) ([]missingNote, error) {
+	if f := resolveDeckFormat(content, opts.Format); !acceptsCommentNotes(f) {
+		return nil, fmt.Errorf("generating missing notes is not supported for %s decks", f)
+	}
+	// source has the same offsets as content, so offsets found in it apply to content
+	slides, source, err := parseDeck(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []missingNote
+	for i, slide := range slides {
+		if len(slide.comments) > 0 || slide.first == nil {
+			continue
+		}
+		var d slideDirectives
+		for _, c := range slide.directives {
+			_ = d.parse(c)
+		}
+		if d.Silence > 0 {
+			continue
+		}
+
+		start, ok := blockStart(slide.first, source)
+		if !ok {
+			return nil, fmt.Errorf("slide %d: cannot locate the slide in the source to insert notes", i+1)
+		}
+		end := len(content)
+		if i+1 < len(slides) && slides[i+1].first != nil {
+			if next, ok := blockStart(slides[i+1].first, source); ok {
+				end = next
+			}
+		}
+		body := trailingBreakPattern.ReplaceAllString(string(source[start:end]), "")
+		missing = append(missing, missingNote{
+			slide:    i + 1,
+			title:    slideTitle(slide.title),
+			markdown: strings.TrimSpace(body),
+			offset:   start,
+		})
+	}
+	return missing, nil
+}
+
+func slideTitle(title string) string {
+	if title == "" {
+		return "(no title)"
+	}
+	return title
+}
+
+// blockStart returns the offset of the beginning of the line where block n starts
+func blockStart(n ast.Node, source []byte) (int, bool) {
+	for n != nil {
+		if lines := n.Lines(); lines != nil && lines.Len() > 0 {
+			start := lineStart(source, lines.At(0).Start)
+			if _, fenced := n.(*ast.FencedCodeBlock); fenced && start > 0 {
+				// The first line of a fenced block is its content; the fence is the line above
+				start = lineStart(source, start-1)
+			}
+			return start, true
+		}
+		if fenced, ok := n.(*ast.FencedCodeBlock); ok && fenced.Info != nil {
+			return lineStart(source, fenced.Info.Segment.Start), true
+		}
+		n = n.FirstChild()
+	}
+	return 0, false
+}
+
+func lineStart(source []byte, offset int) int {
+	return bytes.LastIndexByte(source[:offset], '\n') + 1
+}
+
+// fillMissingNotes asks Gemini for speaker notes for every slide without a comment,
+// shows them as a diff and writes them back into the deck once confirmed
+func fillMissingNotes(ctx context.Context, mdFile string, opts ttsOptions) error {
+	content, err := os.ReadFile(mdFile)
+	if err != nil {
+		return fmt.Errorf("failed to read markdown file: %v", err)
+	}
+	missing, err := findMissingNotes(content, opts.Notes)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// The budget covers the notes about to be generated, before any provider call
+	est, err := estimateWithGeneratedNotes(content, opts.Notes, len(missing))
+	if err != nil {
+		return err
+	}
+	est.Chars *= max(opts.Takes, 1)
+	if err := checkBudget(est, opts); err != nil {
+		return err
+	}
+
+	keyManager, err := NewAPIKeyManager()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Generating notes for %d slides without comments\n", len(missing))
+	generated := make([]string, len(missing))
+	for i, m := range missing {
+		fmt.Printf("[Notes] Slide %03d (%s)\n", m.slide, m.title)
+		note, err := generateNotesWithGemini(ctx, keyManager, m.markdown, opts)
+		if err != nil {
+			return fmt.Errorf("failed to generate notes for slide %d: %w", m.slide, err)
+		}
+		generated[i] = note
+	}
+
+	// Review diff: every change is an inserted comment above the slide's first line
+	fmt.Printf("--- %s\n+++ %s (generated notes)\n", mdFile, mdFile)
+	for i, m := range missing {
+		line := bytes.Count(content[:m.offset], []byte("\n")) + 1
+		fmt.Printf("@@ slide %d, line %d @@\n", m.slide, line)
+		for _, l := range strings.Split(notesComment(generated[i]), "\n") {
+			fmt.Printf("+%s\n", l)
+		}
+	}
+
+	ok, err := confirm(fmt.Sprintf("Write %d generated notes into %s?", len(missing), mdFile), opts.AssumeYes)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("aborted: generated notes were not written")
+	}
+
+	var out bytes.Buffer
+	prev := 0
+	for i, m := range missing {
+		out.Write(content[prev:m.offset])
+		out.WriteString(notesComment(generated[i]) + "\n\n")
+		prev = m.offset
+	}
+	out.Write(content[prev:])
+
+	info, err := os.Stat(mdFile)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(mdFile, out.Bytes(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write notes to %s: %v", mdFile, err)
+	}
+	fmt.Printf("✓ Wrote %d generated notes to %s\n", len(missing), mdFile)
+	return nil
+}
+
+// notesComment formats generated notes as a deck comment
+func notesComment(note string) string {
+	// "--" would end the comment early
+	note = strings.ReplaceAll(note, "--", "—")
+	return "<!--\n" + note + "\n-->"
+}
+
+// generateNotesWithGemini writes speaker notes for one slide's markdown
+func generateNotesWithGemini(ctx context.Context, keyManager *APIKeyManager, slideMarkdown string, opts ttsOptions) (string, error) {
+	policy := opts.Retry
+	policy.MaxAttempts = max(policy.MaxAttempts, keyManager.KeyCount())
+
+	prompt := fmt.Sprintf(`Write the speaker notes a presenter would say aloud for this slide.
+Language: %s
+Write 2 to 4 natural spoken sentences as plain text: no markdown, no lists, no stage directions.
+
+Slide:
+%s`, opts.Language, slideMarkdown)
+
+	var note string
+	err := policy.do(ctx, func(attempt int) error {
+		apiKey, keyIndex := keyManager.NextKey()
+		client, err := genai.NewClient(ctx, &genai.ClientConfig{
+			APIKey:     apiKey,
+			HTTPClient: newHTTPClient(0, opts.Proxy),
+		})
+		if err != nil {
+			return redactErr(err)
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, cmp.Or(opts.Timeout, defaultTTSTimeout))
+		defer cancel()
+		result, err := client.Models.GenerateContent(reqCtx, notesModel, genai.Text(prompt), nil)
+		if err != nil {
+			class := classifyError(err)
+			err = redactErr(err)
+			fmt.Printf("  %s error with API key #%d: %v\n", class, keyIndex, err)
+			if class.retryable() || (class == errorAuth && keyManager.KeyCount() > 1) {
+				return err
+			}
+			return nonRetryable(fmt.Errorf("error generating notes (%s): %w", class, err))
+		}
+
+		note = strings.TrimSpace(result.Text())
+		if note == "" {
+			return fmt.Errorf("no text in response")
+		}
+		return nil
+	})
+	return note, err
+}
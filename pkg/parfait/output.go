@@ -0,0 +1,149 @@
+package parfait
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Values of ttsOptions.AudioFormat
+const (
+	audioFormatWAV  = "wav"
+	audioFormatMP3  = "mp3"
+	audioFormatFLAC = "flac"
+	audioFormatM4A  = "m4a"
+	audioFormatOpus = "opus"
+)
+
+const defaultBitrate = "128k"
+
+// maxMP3Quality is the lowest LAME VBR quality (0 is the best)
+const maxMP3Quality = 9
+
+var (
+	audioFormats   = []string{audioFormatWAV, audioFormatMP3, audioFormatFLAC, audioFormatM4A, audioFormatOpus}
+	bitratePattern = regexp.MustCompile(`^[1-9][0-9]*k$`)
+)
+
+// validateAudioFormat checks the format, bitrate and MP3 VBR quality (-1 = constant bitrate)
+func validateAudioFormat(format, bitrate string, quality int) error {
+	switch format {
+	case "", audioFormatWAV:
+	case audioFormatMP3, audioFormatFLAC, audioFormatM4A, audioFormatOpus:
+		if err := checkFFmpeg(format + " output"); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid audio format: %q. Use %s", format, strings.Join(audioFormats, ", "))
+	}
+	if bitrate != "" && !bitratePattern.MatchString(bitrate) {
+		return fmt.Errorf("invalid bitrate %q: use kilobits per second such as 128k", bitrate)
+	}
+	if quality != -1 && (quality < 0 || quality > maxMP3Quality) {
+		return fmt.Errorf("invalid quality: %d. Use 0 (best) to %d", quality, maxMP3Quality)
+	}
+	if quality != -1 && format != audioFormatMP3 {
+		return fmt.Errorf("--quality applies to --format mp3 only")
+	}
+	return nil
+}
+
+// slideAudioPath returns the final audio path of a slide: the WAV path with the
+// extension of the configured audio format
+func slideAudioPath(outputDir string, slideNum int, opts ttsOptions) string {
+	path := slideOutputPath(outputDir, slideNum, opts)
+	if opts.AudioFormat == "" || opts.AudioFormat == audioFormatWAV {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "." + opts.AudioFormat
+}
+
+// encodeSlides converts the slides' WAV files to the configured audio format, replacing them
+func encodeSlides(ctx context.Context, notes []SlideNote, outputDir string, opts ttsOptions) error {
+	if opts.AudioFormat == "" || opts.AudioFormat == audioFormatWAV {
+		return nil
+	}
+	failed := 0
+	for _, note := range notes {
+		src := slideOutputPath(outputDir, note.SlideNumber, opts)
+		if _, err := os.Stat(src); err != nil {
+			// The slide failed to generate; it was already reported
+			continue
+		}
+		dst := slideAudioPath(outputDir, note.SlideNumber, opts)
+		if err := encodeAudio(ctx, src, dst, slideTags(note, opts.Meta), nil, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to encode slide %03d: %v\n", note.SlideNumber, err)
+			failed++
+			continue
+		}
+		if err := os.Remove(src); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Encoded slide %03d: %s\n", note.SlideNumber, dst)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to encode %d slides to %s", failed, opts.AudioFormat)
+	}
+	return nil
+}
+
+// encodeAudio transcodes a WAV file with ffmpeg, writing the given title, album, etc. tags
+// and chapter markers (nil = none)
+func encodeAudio(ctx context.Context, src, dst string, tags [][2]string, chapters []deckChapter, opts ttsOptions) error {
+	args := []string{"-i", src}
+	if len(chapters) > 0 {
+		meta, err := writeFFMetadata(filepath.Dir(dst), chapters)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(meta)
+		args = append(args, "-f", "ffmetadata", "-i", meta, "-map", "0:a", "-map_chapters", "1")
+	}
+	args = append(args, "-map_metadata", "-1")
+	bitrate := opts.Bitrate
+	if bitrate == "" {
+		bitrate = defaultBitrate
+	}
+	switch opts.AudioFormat {
+	case audioFormatMP3:
+		args = append(args, "-codec:a", "libmp3lame")
+		if opts.Quality != nil {
+			// VBR: the quality replaces the bitrate
+			args = append(args, "-q:a", strconv.Itoa(*opts.Quality))
+		} else {
+			args = append(args, "-b:a", bitrate)
+		}
+		args = append(args, "-id3v2_version", "3")
+	case audioFormatM4A:
+		args = append(args, "-codec:a", "aac", "-b:a", bitrate)
+	case audioFormatOpus:
+		args = append(args, "-codec:a", "libopus", "-b:a", bitrate, "-vbr", "on")
+	case audioFormatFLAC:
+		// Lossless: the bitrate does not apply
+		args = append(args, "-codec:a", "flac", "-compression_level", "8")
+	}
+	for _, t := range tags {
+		if t[1] != "" {
+			args = append(args, "-metadata", t[0]+"="+t[1])
+		}
+	}
+	return writeAtomic(dst, func(tmp string) error {
+		return runFFmpeg(ctx, append(args, tmp)...)
+	})
+}
+
+// slideTags carries the deck and slide metadata over to an encoded slide
+func slideTags(note SlideNote, meta deckMeta) [][2]string {
+	return [][2]string{
+		{"title", note.displayTitle()},
+		{"album", meta.Title},
+		{"artist", meta.Author},
+		{"date", meta.Date},
+		{"track", strconv.Itoa(note.SlideNumber)},
+		{"language", meta.Language},
+	}
+}
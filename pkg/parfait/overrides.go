@@ -0,0 +1,54 @@
+package parfait
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+// defaultOverridesDir holds hand recordings next to the deck, named like the slide
+// files (007.wav replaces slide 7 with the default naming)
+const defaultOverridesDir = "overrides"
+
+// findOverrides maps slides to the recordings in dir that replace their synthesis
+func findOverrides(dir string, notes []SlideNote, opts ttsOptions) map[int]string {
+	recordings := make(map[int]string)
+	if dir == "" {
+		return recordings
+	}
+	for _, note := range notes {
+		path := slideOutputPath(dir, note.SlideNumber, opts)
+		if statOK(path) {
+			recordings[note.SlideNumber] = path
+		}
+	}
+	return recordings
+}
+
+// withoutSlides returns the notes of slides not in skip
+func withoutSlides[V any](notes []SlideNote, skip map[int]V) []SlideNote {
+	return slices.DeleteFunc(slices.Clone(notes), func(n SlideNote) bool {
+		_, ok := skip[n.SlideNumber]
+		return ok
+	})
+}
+
+// useRecording writes a hand recording as the slide's audio. It gets the same format
+// conversion, levels, fades and silence as synthesized slides, but keeps its tempo.
+func useRecording(recording string, note SlideNote, outputPath string, opts ttsOptions) error {
+	data, err := os.ReadFile(recording)
+	if err != nil {
+		return err
+	}
+	buf, err := decodeWAV(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", recording, err)
+	}
+	buf = convertChannels(resample(buf, opts.SampleRate), opts.Channels)
+	masterSlideAudio(buf, note, opts)
+	if err := writeWAVBuffer(outputPath, buf, opts.Meta.wavMetadata(note)); err != nil {
+		return fmt.Errorf("error saving WAV file: %v", err)
+	}
+	fmt.Printf("✓ Saved slide %03d: %s (recorded, from %s)\n", note.SlideNumber, outputPath, recording)
+	return nil
+}
@@ -0,0 +1,23 @@
+// Package parfait generates narration audio from the speaker notes of slide decks.
+// The parfait command is a thin wrapper around it; embedding applications (a web UI,
+// a REST server) call Run or RunPipeline and follow progress through Options.Hooks.
+package parfait
+
+import "context"
+
+// Options configures a run. Language is required; other fields left at their zero
+// value are off or use the provider's defaults (one attempt per request, 30s timeout,
+// KokoVox). Gemini API keys are read from the environment.
+type Options = ttsOptions
+
+// Run synthesizes one WAV file per slide of the deck at mdFile into outputDir.
+// Progress is reported to opts.Hooks.
+func Run(ctx context.Context, mdFile, outputDir string, opts Options) error {
+	return runTTSGeneration(ctx, mdFile, outputDir, opts)
+}
+
+// RunPipeline runs the stages of a pipeline.yaml file. opts are the base options of
+// its tts stages; slide progress and stage completions are reported to opts.Hooks.
+func RunPipeline(ctx context.Context, path string, opts Options) error {
+	return runPipeline(ctx, path, &pipelineRun{opts: opts}, false)
+}
@@ -0,0 +1,60 @@
+package parfait
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultPauseMarker is the length of a bare [pause]
+const defaultPauseMarker = time.Second
+
+// pauseMarkerPattern matches [pause], [pause:2s] and SSML-style <break time="1s"/>.
+// Providers don't interpret these, so they are cut out and rendered as silence.
+var pauseMarkerPattern = regexp.MustCompile(`(?i)\[pause(?::\s*([^\]\s]+))?\s*\]|<break\s+time\s*=\s*["']([^"']+)["']\s*/?>`)
+
+// speechPart is either text to synthesize or a pause
+type speechPart struct {
+	text  string
+	pause time.Duration
+}
+
+// splitPauses splits narration at pause markers
+func splitPauses(text string) ([]speechPart, error) {
+	var parts []speechPart
+	addText := func(s string) {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, speechPart{text: s})
+		}
+	}
+
+	last := 0
+	for _, m := range pauseMarkerPattern.FindAllStringSubmatchIndex(text, -1) {
+		addText(text[last:m[0]])
+		last = m[1]
+
+		pause := defaultPauseMarker
+		value := ""
+		if m[2] >= 0 {
+			value = text[m[2]:m[3]]
+		} else if m[4] >= 0 {
+			value = text[m[4]:m[5]]
+		}
+		if value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid pause %q: use a duration such as 2s or 500ms", text[m[0]:m[1]])
+			}
+			pause = d
+		}
+		parts = append(parts, speechPart{pause: pause})
+	}
+	addText(text[last:])
+	return parts, nil
+}
+
+// stripPauseMarkers removes pause markers, e.g. for character counts
+func stripPauseMarkers(text string) string {
+	return pauseMarkerPattern.ReplaceAllString(text, " ")
+}
@@ -0,0 +1,459 @@
+package parfait
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// pipelineFile is the schema of pipeline.yaml
+type pipelineFile struct {
+	// Language is the default for stages that don't set `lang`
+	Language string `yaml:"language"`
+	// Timeout bounds the whole pipeline run (Go duration, e.g. "30m")
+	Timeout string          `yaml:"timeout"`
+	Stages  []pipelineStage `yaml:"stages"`
+}
+
+// pipelineStage is one node of the stage graph
+type pipelineStage struct {
+	Name string `yaml:"name"`
+	// Use selects the stage implementation (see pipelineStageRunners)
+	Use string `yaml:"use"`
+	// Needs lists stages that must finish first
+	Needs []string `yaml:"needs"`
+	// Timeout bounds this stage; subprocesses are interrupted, then killed
+	Timeout string            `yaml:"timeout"`
+	With    map[string]string `yaml:"with"`
+}
+
+// pipelineRun carries state shared by the stages of one run
+type pipelineRun struct {
+	file pipelineFile
+	// dir is the directory of pipeline.yaml; relative paths resolve against it
+	dir string
+	// opts are the caller's synthesis options (timeouts, proxy, retries, budget, cache,
+	// hooks, ...); tts stages start from them and apply their own with: settings
+	opts ttsOptions
+	// noCache disables the default cache in each tts stage's output directory
+	noCache       bool
+	healthTimeout time.Duration
+}
+
+type pipelineStageRunner func(ctx context.Context, run *pipelineRun, st pipelineStage) error
+
+var pipelineStageRunners = map[string]pipelineStageRunner{
+	"notes": runNotesStage,
+	"tts":   runTTSStage,
+	"exec":  runExecStage,
+}
+
+// loadPipelineFile parses and validates a pipeline definition
+func loadPipelineFile(path string) (pipelineFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return pipelineFile{}, err
+	}
+	var pf pipelineFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&pf); err != nil {
+		return pipelineFile{}, fmt.Errorf("invalid pipeline file (%s): %w", path, err)
+	}
+	if len(pf.Stages) == 0 {
+		return pipelineFile{}, fmt.Errorf("pipeline file (%s) has no stages", path)
+	}
+
+	if _, err := parseStageTimeout(pf.Timeout); err != nil {
+		return pipelineFile{}, fmt.Errorf("pipeline timeout: %w", err)
+	}
+
+	seen := make(map[string]bool, len(pf.Stages))
+	for i, st := range pf.Stages {
+		if st.Name == "" {
+			return pipelineFile{}, fmt.Errorf("stage %d has no name", i+1)
+		}
+		if seen[st.Name] {
+			return pipelineFile{}, fmt.Errorf("duplicate stage name %q", st.Name)
+		}
+		seen[st.Name] = true
+		if _, ok := pipelineStageRunners[st.Use]; !ok {
+			return pipelineFile{}, fmt.Errorf("stage %q: unknown stage type %q (available: %s)", st.Name, st.Use, strings.Join(pipelineStageTypes(), ", "))
+		}
+		if _, err := parseStageTimeout(st.Timeout); err != nil {
+			return pipelineFile{}, fmt.Errorf("stage %q timeout: %w", st.Name, err)
+		}
+	}
+	for _, st := range pf.Stages {
+		for _, dep := range st.Needs {
+			if !seen[dep] {
+				return pipelineFile{}, fmt.Errorf("stage %q needs unknown stage %q", st.Name, dep)
+			}
+		}
+	}
+	return pf, nil
+}
+
+// parseStageTimeout parses an optional duration; empty means no limit
+func parseStageTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func pipelineStageTypes() []string {
+	types := make([]string, 0, len(pipelineStageRunners))
+	for k := range pipelineStageRunners {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// orderPipelineStages returns the stages in dependency order, keeping file order where possible
+func orderPipelineStages(stages []pipelineStage) ([]pipelineStage, error) {
+	done := make(map[string]bool, len(stages))
+	var ordered []pipelineStage
+	for len(ordered) < len(stages) {
+		progressed := false
+		for _, st := range stages {
+			if done[st.Name] {
+				continue
+			}
+			ready := true
+			for _, dep := range st.Needs {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, st)
+				done[st.Name] = true
+				progressed = true
+			}
+		}
+		if !progressed {
+			var pending []string
+			for _, st := range stages {
+				if !done[st.Name] {
+					pending = append(pending, st.Name)
+				}
+			}
+			return nil, fmt.Errorf("pipeline has a dependency cycle between stages: %s", strings.Join(pending, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+// path resolves a stage path relative to the pipeline file
+func (r *pipelineRun) path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(r.dir, p)
+}
+
+// require returns a mandatory `with` parameter
+func (st pipelineStage) require(key string) (string, error) {
+	v := strings.TrimSpace(st.With[key])
+	if v == "" {
+		return "", fmt.Errorf("stage %q (%s) requires with.%s", st.Name, st.Use, key)
+	}
+	return v, nil
+}
+
+// runNotesStage extracts slide notes and writes them as JSON (e.g. for an external translate step)
+func runNotesStage(ctx context.Context, run *pipelineRun, st pipelineStage) error {
+	input, err := st.require("input")
+	if err != nil {
+		return err
+	}
+	output, err := st.require("output")
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(run.path(input))
+	if err != nil {
+		return fmt.Errorf("failed to read markdown file: %v", err)
+	}
+	deck, err := loadDeckConfig(content)
+	if err != nil {
+		return err
+	}
+	notesOpts := notesOptions{
+		Fallback:     st.With["fallback"],
+		Format:       deckFormatForFile(input, st.With["format"]),
+		SkipComments: st.With["skip_comments"],
+		Delimiter:    cmp.Or(st.With["delimiter"], deck.Delimiter),
+	}
+	if err := notesOpts.validate(); err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+	notes, err := extractNotesFromMarkdown(content, notesOpts)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	out := run.path(output)
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote %d notes to %s\n", len(notes), out)
+	return nil
+}
+
+// runTTSStage synthesizes narration for a markdown deck
+func runTTSStage(ctx context.Context, run *pipelineRun, st pipelineStage) error {
+	input, err := st.require("input")
+	if err != nil {
+		return err
+	}
+	input = run.path(input)
+
+	output := run.path(st.With["output"])
+	if output == "" {
+		output = filepath.Dir(input)
+	}
+
+	deck, err := readDeckConfig(input)
+	if err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+
+	lang := cmp.Or(st.With["lang"], deck.Language, run.file.Language)
+	lang, err = normalizeLanguage(lang)
+	if err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+
+	tone := cmp.Or(st.With["tone"], deck.Tone)
+	if err := validateTone(tone); err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+
+	var lex *lexicon
+	if path := st.With["lexicon"]; path != "" {
+		if lex, err = loadLexicon(run.path(path), lang); err != nil {
+			return fmt.Errorf("stage %q: %w", st.Name, err)
+		}
+	}
+
+	normalize := st.With["normalize"] != "false"
+	acronyms, err := loadAcronyms(run.path(st.With["acronyms"]), lang, normalize)
+	if err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+
+	if err := validateURLPolicy(st.With["urls"]); err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+	if err := validateEmojiPolicy(st.With["emoji"]); err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+	notesOpts := notesOptions{
+		Fallback:     st.With["notes_fallback"],
+		Format:       deckFormatForFile(input, st.With["format"]),
+		SkipComments: st.With["skip_comments"],
+		Delimiter:    cmp.Or(st.With["delimiter"], deck.Delimiter),
+	}
+	if err := notesOpts.validate(); err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+	trailingSilence, trailingSilenceSet, err := parseTrailingSilence(st.With["trailing_silence"])
+	if err != nil {
+		return fmt.Errorf("stage %q: %w", st.Name, err)
+	}
+
+	provider := cmp.Or(st.With["provider"], deck.Provider, "kokovox")
+	if provider != "kokovox" && provider != "gemini" {
+		return fmt.Errorf("stage %q: invalid provider: %q. Use kokovox or gemini", st.Name, provider)
+	}
+	useGemini := provider == "gemini"
+	if useGemini {
+		if err := validateGeminiLanguage(lang); err != nil {
+			return fmt.Errorf("stage %q: %w", st.Name, err)
+		}
+	}
+
+	if err := applyGlobalEnvDefaults(useGemini); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load global config: %v\n", err)
+	}
+	if !useGemini {
+		if err := checkKokoVoxHealth(lang, cmp.Or(run.healthTimeout, defaultHealthTimeout), run.opts.Proxy); err != nil {
+			return err
+		}
+	}
+
+	opts := run.opts
+	opts.Language = lang
+	opts.UseGemini = useGemini
+	opts.Silence = defaultTrailingSilence(useGemini)
+	opts.SegmentPause = cmp.Or(opts.SegmentPause, defaultSegmentPause)
+	opts.Lexicon = lex
+	opts.Acronyms = acronyms
+	opts.Normalize = normalize
+	opts.URLPolicy = st.With["urls"]
+	opts.EmojiPolicy = st.With["emoji"]
+	opts.StripMarkdown = st.With["strip_markdown"] != "false"
+	opts.Notes = notesOpts
+	if opts.Cache.dir == "" && !run.noCache {
+		opts.Cache = ttsCache{dir: filepath.Join(output, defaultCacheDir)}
+	}
+	applyTone(&opts, tone)
+	deck.applyTo(&opts)
+	if trailingSilenceSet {
+		opts.Silence = trailingSilence
+	}
+	return runTTSGeneration(ctx, input, output, opts)
+}
+
+// runExecStage runs a shell command, the escape hatch for post-processing and publishing
+func runExecStage(ctx context.Context, run *pipelineRun, st pipelineStage) error {
+	command, err := st.require("run")
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	setStageCancel(cmd)
+	cmd.WaitDelay = 10 * time.Second
+	cmd.Dir = run.dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "PARFAIT_STAGE="+st.Name, "PARFAIT_LANG="+run.file.Language)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("stage %q: command failed: %v", st.Name, err)
+	}
+	return nil
+}
+
+var (
+	pipelineDryRunFlag  bool
+	pipelineYesFlag     bool
+	pipelineNoCacheFlag bool
+	pipelineProxyFlag   string
+)
+
+// pipelineOptions resolves the synthesis options the pipeline command shares with the
+// root command's defaults: environment and global config timeouts and budget, retries
+// and the proxy
+func pipelineOptions() (ttsOptions, time.Duration, error) {
+	if err := applyGlobalEnvDefaults(false); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load global config: %v\n", err)
+	}
+	ttsTimeout, err := resolveTimeout(0, ttsTimeoutEnv, defaultTTSTimeout)
+	if err != nil {
+		return ttsOptions{}, 0, err
+	}
+	healthTimeout, err := resolveTimeout(0, healthTimeoutEnv, defaultHealthTimeout)
+	if err != nil {
+		return ttsOptions{}, 0, err
+	}
+	var maxChars int
+	if v := os.Getenv(maxCharsEnv); v != "" {
+		if maxChars, err = strconv.Atoi(v); err != nil {
+			return ttsOptions{}, 0, fmt.Errorf("invalid %s: %q", maxCharsEnv, v)
+		}
+	}
+	opts := ttsOptions{
+		AssumeYes:        pipelineYesFlag,
+		Retry:            defaultRetryPolicy(),
+		Proxy:            pipelineProxyFlag,
+		Timeout:          ttsTimeout,
+		BreakerThreshold: defaultBreakerThreshold,
+		MaxChars:         maxChars,
+		SegmentPause:     defaultSegmentPause,
+	}
+	return opts, healthTimeout, nil
+}
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline <pipeline.yaml>",
+	Short: "Run a declarative pipeline of stages",
+	Long: `Run the stage graph described in a pipeline file.
+
+Stage types:
+  notes  extract slide notes to JSON   (with: input, output, fallback, format, skip_comments, delimiter)
+  tts    synthesize narration          (with: input, output, provider, lang, tone, lexicon, acronyms, normalize, urls, emoji, strip_markdown, notes_fallback, format, skip_comments, delimiter, trailing_silence)
+  exec   run a shell command           (with: run)
+
+Stages run in dependency order (needs:), otherwise in file order.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, healthTimeout, err := pipelineOptions()
+		if err != nil {
+			return err
+		}
+		run := &pipelineRun{opts: opts, noCache: pipelineNoCacheFlag, healthTimeout: healthTimeout}
+		return runPipeline(cmd.Context(), args[0], run, pipelineDryRunFlag)
+	},
+}
+
+// runPipeline loads a pipeline file and runs its stages in order with run's settings.
+// dryRun only prints the stage order.
+func runPipeline(ctx context.Context, path string, run *pipelineRun, dryRun bool) error {
+	pf, err := loadPipelineFile(path)
+	if err != nil {
+		return err
+	}
+	stages, err := orderPipelineStages(pf.Stages)
+	if err != nil {
+		return err
+	}
+	run.file = pf
+	run.dir = filepath.Dir(path)
+
+	runTimeout, _ := parseStageTimeout(pf.Timeout)
+	ctx, cancel := withOptionalTimeout(ctx, runTimeout, "pipeline")
+	defer cancel()
+
+	for i, st := range stages {
+		fmt.Printf("[Pipeline] Stage %d/%d: %s (%s)\n", i+1, len(stages), st.Name, st.Use)
+		if dryRun {
+			continue
+		}
+		timeout, _ := parseStageTimeout(st.Timeout)
+		stageCtx, stageCancel := withOptionalTimeout(ctx, timeout, fmt.Sprintf("stage %q", st.Name))
+		err := pipelineStageRunners[st.Use](stageCtx, run, st)
+		err = annotateTimeout(stageCtx, err)
+		stageCancel()
+		hooksOrNoop(run.opts.Hooks).OnStageDone(st.Name, err)
+		if err != nil {
+			return fmt.Errorf("stage %q failed: %w", st.Name, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	pipelineCmd.Flags().BoolVar(&pipelineDryRunFlag, "dry-run", false, "Validate the pipeline and print the stage order without running it")
+	pipelineCmd.Flags().BoolVarP(&pipelineYesFlag, "yes", "y", false, "Skip confirmation prompts")
+	pipelineCmd.Flags().BoolVar(&pipelineNoCacheFlag, "no-cache", false, "Synthesize every slide again instead of reusing the cache in each tts stage's output directory")
+	pipelineCmd.Flags().StringVar(&pipelineProxyFlag, "proxy", "", "Proxy URL for KokoVox and Gemini requests (default: HTTP_PROXY/HTTPS_PROXY, NO_PROXY is honored)")
+}
@@ -0,0 +1,59 @@
+package parfait
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+var noteMarkdown = goldmark.New()
+
+// markdownToPlain renders markdown inside note text as the words it displays:
+// "[docs](https://…)" becomes "docs", emphasis and backticks are dropped.
+func markdownToPlain(note string) string {
+	if !strings.ContainsAny(note, "*_`[]#<>!~-+|") {
+		return note
+	}
+	source := []byte(note)
+	doc := noteMarkdown.Parser().Parse(text.NewReader(source))
+	return nodeText(doc, source, true)
+}
+
+// nodeText collects the visible text below n; code blocks are included only with withCode
+func nodeText(n ast.Node, source []byte, withCode bool) string {
+	var b strings.Builder
+	ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if n.Type() == ast.TypeBlock && n.NextSibling() != nil {
+				b.WriteByte('\n')
+			}
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte('\n')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		case *ast.AutoLink:
+			b.Write(n.URL(source))
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			if withCode {
+				lines := n.Lines()
+				for i := 0; i < lines.Len(); i++ {
+					seg := lines.At(i)
+					b.Write(seg.Value(source))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML, *ast.HTMLBlock:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,239 @@
+package parfait
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	pptxPresentation   = "ppt/presentation.xml"
+	pptxNotesSlideType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide"
+	pptxRelsNamespace  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+// pptxSlideInfos reads the slides of a PowerPoint deck in presentation order.
+// The speaker notes of each slide are its narration; a notes paragraph starting with
+// "parfait:" is a directive. Hidden slides are skipped, as in a slide show.
+func pptxSlideInfos(content []byte) ([]slideInfo, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PowerPoint file: %v", err)
+	}
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	var pres struct {
+		Slides []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err := readPPTXPart(parts, pptxPresentation, &pres); err != nil {
+		return nil, err
+	}
+	presRels, err := readPPTXRels(parts, pptxPresentation)
+	if err != nil {
+		return nil, err
+	}
+
+	var slides []slideInfo
+	for _, s := range pres.Slides {
+		rel, ok := presRels[s.RID]
+		if !ok {
+			return nil, fmt.Errorf("invalid PowerPoint file: slide %s has no relationship", s.RID)
+		}
+		slidePath := pptxTarget(pptxPresentation, rel.Target)
+
+		var slide pptxSlide
+		if err := readPPTXPart(parts, slidePath, &slide); err != nil {
+			return nil, err
+		}
+		if slide.Show != nil && !*slide.Show {
+			continue
+		}
+		info := slideInfo{}
+		for _, sh := range slide.Shapes {
+			text := sh.text()
+			if text == "" {
+				continue
+			}
+			if ph := sh.placeholder(); (ph == "title" || ph == "ctrTitle") && info.title == "" {
+				info.title = strings.ReplaceAll(text, "\n", " ")
+			}
+			info.body = append(info.body, text)
+		}
+
+		slideRels, err := readPPTXRels(parts, slidePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range slideRels {
+			if r.Type != pptxNotesSlideType {
+				continue
+			}
+			var notes pptxSlide
+			if err := readPPTXPart(parts, pptxTarget(slidePath, r.Target), &notes); err != nil {
+				return nil, err
+			}
+			info.addNotes(notes)
+		}
+		slides = append(slides, info)
+	}
+	return slides, nil
+}
+
+// addNotes takes the text of the notes page's body placeholder
+func (s *slideInfo) addNotes(notes pptxSlide) {
+	var lines []string
+	for _, sh := range notes.Shapes {
+		if sh.placeholder() != "body" {
+			continue
+		}
+		for _, p := range sh.Paragraphs {
+			line := strings.TrimSpace(p.Text)
+			if isDirectiveComment(line) {
+				s.directives = append(s.directives, line)
+				continue
+			}
+			lines = append(lines, line)
+		}
+	}
+	if note := strings.TrimSpace(strings.Join(lines, "\n")); note != "" {
+		s.comments = append(s.comments, note)
+	}
+}
+
+// pptxSlide is a slide or notes page: its shapes and, for slides, whether it is shown
+type pptxSlide struct {
+	Show   *bool       `xml:"show,attr"`
+	Shapes []pptxShape `xml:"cSld>spTree>sp"`
+}
+
+type pptxShape struct {
+	Placeholder *struct {
+		Type string `xml:"type,attr"`
+	} `xml:"nvSpPr>nvPr>ph"`
+	Paragraphs []pptxParagraph `xml:"txBody>p"`
+}
+
+// placeholder returns the placeholder type ("obj" when the ph element has none), or "" for plain shapes
+func (sh pptxShape) placeholder() string {
+	if sh.Placeholder == nil {
+		return ""
+	}
+	if sh.Placeholder.Type == "" {
+		return "obj"
+	}
+	return sh.Placeholder.Type
+}
+
+func (sh pptxShape) text() string {
+	var lines []string
+	for _, p := range sh.Paragraphs {
+		if t := strings.TrimSpace(p.Text); t != "" {
+			lines = append(lines, t)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pptxParagraph is the text of an a:p element: its runs and fields, with line breaks
+type pptxParagraph struct {
+	Text string
+}
+
+func (p *pptxParagraph) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var b strings.Builder
+	inText := false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "br":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name == start.Name {
+				p.Text = b.String()
+				return nil
+			}
+			if t.Name.Local == "t" {
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
+
+type pptxRel struct {
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+	Target string `xml:"Target,attr"`
+}
+
+// readPPTXRels reads the relationships of a part, keyed by ID
+func readPPTXRels(parts map[string]*zip.File, part string) (map[string]pptxRel, error) {
+	relsPath := path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+	if _, ok := parts[relsPath]; !ok {
+		return nil, nil
+	}
+	var rels struct {
+		Rels []pptxRel `xml:"Relationship"`
+	}
+	if err := readPPTXPart(parts, relsPath, &rels); err != nil {
+		return nil, err
+	}
+	out := make(map[string]pptxRel, len(rels.Rels))
+	for _, r := range rels.Rels {
+		out[r.ID] = r
+	}
+	return out, nil
+}
+
+// pptxTarget resolves a relationship target against the part that references it
+func pptxTarget(part, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(path.Dir(part), target)
+}
+
+func readPPTXPart(parts map[string]*zip.File, name string, v any) error {
+	f, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("invalid PowerPoint file: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if err := xml.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("invalid PowerPoint file: %s: %v", name, err)
+	}
+	return nil
+}
+
+// isPPTX reports whether content is a zip archive, as .pptx files are
+func isPPTX(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("PK\x03\x04"))
+}
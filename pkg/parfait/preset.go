@@ -0,0 +1,78 @@
+package parfait
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// audioPreset bundles the encoding and post-processing settings for a kind of output
+type audioPreset struct {
+	// Bitrate of compressed audio formats
+	Bitrate string
+	// SampleRate and Channels of the slide audio (0 = provider's)
+	SampleRate int
+	Channels   int
+	// Loudness is the integrated loudness target in LUFS (0 = off)
+	Loudness float64
+	// Fade smooths the start and end of each slide
+	Fade time.Duration
+}
+
+var audioPresets = map[string]audioPreset{
+	// draft keeps files small for quick review: low bitrate mono, no loudness pass
+	"draft": {
+		Bitrate:    "64k",
+		SampleRate: 24000,
+		Channels:   1,
+	},
+	// publish targets podcast and video platforms: -16 LUFS at 48 kHz
+	"publish": {
+		Bitrate:    "192k",
+		SampleRate: 48000,
+		Loudness:   -16,
+		Fade:       10 * time.Millisecond,
+	},
+}
+
+func audioPresetNames() []string {
+	names := make([]string, 0, len(audioPresets))
+	for k := range audioPresets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateAudioPreset reports unknown preset names; empty means no preset
+func validateAudioPreset(name string) error {
+	if _, ok := audioPresets[name]; name != "" && !ok {
+		return fmt.Errorf("invalid preset: %q. Use %s", name, strings.Join(audioPresetNames(), ", "))
+	}
+	return nil
+}
+
+// applyAudioPreset sets the preset's encoding and post-processing options, except
+// those whose flag was set explicitly (changed reports that by flag name)
+func applyAudioPreset(opts *ttsOptions, name string, changed func(flag string) bool) {
+	preset, ok := audioPresets[name]
+	if !ok {
+		return
+	}
+	if !changed("bitrate") {
+		opts.Bitrate = preset.Bitrate
+	}
+	if !changed("sample-rate") {
+		opts.SampleRate = preset.SampleRate
+	}
+	if !changed("channels") {
+		opts.Channels = preset.Channels
+	}
+	if !changed("loudness") {
+		opts.Loudness = preset.Loudness
+	}
+	if !changed("fade") {
+		opts.Fade = preset.Fade
+	}
+}
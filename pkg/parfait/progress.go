@@ -0,0 +1,60 @@
+package parfait
+
+// ProgressHooks receives progress notifications from a run so embedding code
+// (a web UI, a REST server) can render progress without parsing logs.
+// Slide callbacks are invoked concurrently from worker goroutines.
+type ProgressHooks interface {
+	OnSlideStart(slide int, chars int)
+	OnSlideDone(slide int, outputPath string, err error)
+	OnStageDone(stage string, err error)
+}
+
+// ProgressEventKind identifies a ProgressEvent
+type ProgressEventKind string
+
+const (
+	EventSlideStart ProgressEventKind = "slide_start"
+	EventSlideDone  ProgressEventKind = "slide_done"
+	EventStageDone  ProgressEventKind = "stage_done"
+)
+
+// ProgressEvent is the channel form of a ProgressHooks callback
+type ProgressEvent struct {
+	Kind       ProgressEventKind
+	Slide      int
+	Chars      int
+	OutputPath string
+	Stage      string
+	Err        error
+}
+
+// ChannelHooks adapts ProgressHooks to an events channel. Sends block, so the
+// consumer must keep draining the channel until the run returns.
+type ChannelHooks chan<- ProgressEvent
+
+func (c ChannelHooks) OnSlideStart(slide int, chars int) {
+	c <- ProgressEvent{Kind: EventSlideStart, Slide: slide, Chars: chars}
+}
+
+func (c ChannelHooks) OnSlideDone(slide int, outputPath string, err error) {
+	c <- ProgressEvent{Kind: EventSlideDone, Slide: slide, OutputPath: outputPath, Err: err}
+}
+
+func (c ChannelHooks) OnStageDone(stage string, err error) {
+	c <- ProgressEvent{Kind: EventStageDone, Stage: stage, Err: err}
+}
+
+// noopHooks is used when no hooks are configured
+type noopHooks struct{}
+
+func (noopHooks) OnSlideStart(int, int)          {}
+func (noopHooks) OnSlideDone(int, string, error) {}
+func (noopHooks) OnStageDone(string, error)      {}
+
+// hooksOrNoop returns h, or a no-op implementation when h is nil
+func hooksOrNoop(h ProgressHooks) ProgressHooks {
+	if h == nil {
+		return noopHooks{}
+	}
+	return h
+}
@@ -0,0 +1,295 @@
+package parfait
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Values of qaOptions.Backend
+const (
+	qaWhisperCpp = "whisper-cpp"
+	qaOpenAI     = "openai"
+)
+
+var qaBackends = []string{qaWhisperCpp, qaOpenAI}
+
+const (
+	defaultQAThreshold   = 0.8
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIQAModel = "whisper-1"
+	// whisperSampleRate is the input rate whisper.cpp expects
+	whisperSampleRate = 16000
+	// whisperCppEnv names the whisper.cpp binary when it is not whisper-cli in PATH
+	whisperCppEnv = "WHISPER_CPP"
+	// qaMissingShown caps the missing words listed per slide
+	qaMissingShown = 5
+)
+
+// qaOptions configures the transcription check of generated narration
+type qaOptions struct {
+	// Backend transcribes the audio: whisper-cpp or openai ("" = off)
+	Backend string
+	// Model is the whisper.cpp model file or the OpenAI model name
+	Model string
+	// Threshold is the lowest accepted share of the notes found in the transcript
+	Threshold float64
+}
+
+func (o qaOptions) validate() error {
+	if o.Backend == "" {
+		return nil
+	}
+	if err := (transcriber{Backend: o.Backend, Model: o.Model}).validate("qa"); err != nil {
+		return err
+	}
+	if o.Threshold <= 0 || o.Threshold > 1 {
+		return fmt.Errorf("QA threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// transcriber is a speech-to-text backend with its model
+type transcriber struct {
+	// Backend is whisper-cpp or openai ("" = off)
+	Backend string
+	// Model is the whisper.cpp model file or the OpenAI model name
+	Model string
+}
+
+// validate checks the backend configured by --<flag> and --<flag>-model
+func (t transcriber) validate(flag string) error {
+	switch t.Backend {
+	case "", qaOpenAI:
+	case qaWhisperCpp:
+		if t.Model == "" {
+			return fmt.Errorf("--%s whisper-cpp needs --%s-model with the path of a ggml model file", flag, flag)
+		}
+		if _, err := exec.LookPath(whisperCppBinary()); err != nil {
+			return fmt.Errorf("--%s whisper-cpp needs %s in PATH (or set %s)", flag, whisperCppBinary(), whisperCppEnv)
+		}
+	default:
+		return fmt.Errorf("invalid transcription backend for --%s: %q. Use %s", flag, t.Backend, strings.Join(qaBackends, ", "))
+	}
+	return nil
+}
+
+func whisperCppBinary() string {
+	return cmp.Or(os.Getenv(whisperCppEnv), "whisper-cli")
+}
+
+// checkNarration transcribes every slide's WAV file and compares it with the notes,
+// returning a warning per slide whose transcript misses too much of them
+func checkNarration(ctx context.Context, notes []SlideNote, outputDir string, opts ttsOptions) []string {
+	var warnings []string
+	for _, note := range notes {
+		if note.Directives.Silence > 0 {
+			continue
+		}
+		path := slideOutputPath(outputDir, note.SlideNumber, opts)
+		if !statOK(path) {
+			continue
+		}
+		fmt.Printf("[QA] Transcribing slide %03d\n", note.SlideNumber)
+		transcript, err := transcribe(ctx, path, transcriber{Backend: opts.QA.Backend, Model: opts.QA.Model}, opts)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("slide %03d: transcription failed: %v", note.SlideNumber, redactErr(err)))
+			continue
+		}
+		slideOpts := note.Directives.applyTo(opts)
+		score, missing := compareTranscript(note.spokenText(slideOpts), transcript, slideOpts.Language)
+		if score >= opts.QA.Threshold {
+			continue
+		}
+		w := fmt.Sprintf("slide %03d: transcript matches %.0f%% of the notes", note.SlideNumber, score*100)
+		if len(missing) > 0 {
+			w += "; not heard: " + strings.Join(missing, ", ")
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
+
+func transcribe(ctx context.Context, path string, t transcriber, opts ttsOptions) (string, error) {
+	if t.Backend == qaOpenAI {
+		body, err := transcribeOpenAI(ctx, path, t, "text", opts)
+		return strings.TrimSpace(string(body)), err
+	}
+	out, err := transcribeWhisperCpp(ctx, path, t, []string{"-nt"}, opts)
+	return strings.TrimSpace(out), err
+}
+
+// transcribeWhisperCpp runs the whisper.cpp CLI with extra arguments on a 16kHz mono copy
+// of the slide and returns its standard output
+func transcribeWhisperCpp(ctx context.Context, path string, t transcriber, args []string, opts ttsOptions) (string, error) {
+	buf, err := readSlideWAV(path)
+	if err != nil {
+		return "", err
+	}
+	buf = convertChannels(resample(buf, whisperSampleRate), 1)
+	tmp, err := os.CreateTemp("", "parfait-qa-*.wav")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := writeWAVBuffer(tmp.Name(), buf, nil); err != nil {
+		return "", err
+	}
+
+	args = append([]string{"-m", t.Model, "-f", tmp.Name(), "-l", baseLanguage(opts.Language), "-np"}, args...)
+	cmd := exec.CommandContext(ctx, whisperCppBinary(), args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", filepath.Base(whisperCppBinary()), msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// transcribeOpenAI sends the slide to an OpenAI-compatible transcription endpoint
+// (OPENAI_BASE_URL, default the OpenAI API) and returns the response in the given format
+func transcribeOpenAI(ctx context.Context, path string, t transcriber, format string, opts ttsOptions) ([]byte, error) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY is not set (or use parfait config set openai.api_key)")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields := [][2]string{
+		{"model", cmp.Or(t.Model, defaultOpenAIQAModel)},
+		{"language", baseLanguage(opts.Language)},
+		{"response_format", format},
+	}
+	if format == "verbose_json" {
+		fields = append(fields, [2]string{"timestamp_granularities[]", "word"})
+	}
+	for _, f := range fields {
+		if err := form.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(opts.Timeout, 30*time.Second))
+	defer cancel()
+	url := strings.TrimSuffix(cmp.Or(os.Getenv("OPENAI_BASE_URL"), defaultOpenAIBaseURL), "/") + "/audio/transcriptions"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	resp, err := newHTTPClient(0, opts.Proxy).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	text, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Body: redact(string(text))}
+	}
+	return text, nil
+}
+
+// compareTranscript scores how much of the notes the transcript contains: one minus the
+// edit distance between their words (characters for Japanese and Chinese) relative to the
+// notes' length. missing lists note words the transcript does not contain at all.
+func compareTranscript(notes, transcript, lang string) (float64, []string) {
+	want := qaTokens(notes, lang)
+	got := qaTokens(transcript, lang)
+	if len(want) == 0 {
+		return 1, nil
+	}
+	score := max(0, 1-float64(editDistance(want, got))/float64(len(want)))
+
+	var missing []string
+	if !charTokens(lang) {
+		for _, w := range want {
+			if !slices.Contains(got, w) && !slices.Contains(missing, w) && len(missing) < qaMissingShown {
+				missing = append(missing, w)
+			}
+		}
+	}
+	return score, missing
+}
+
+// charTokens reports whether lang is written without spaces between words
+func charTokens(lang string) bool {
+	switch baseLanguage(lang) {
+	case "ja", "zh":
+		return true
+	}
+	return false
+}
+
+// qaTokens lowercases text and splits it into words, or letters for languages written
+// without spaces; punctuation is dropped
+func qaTokens(text, lang string) []string {
+	text = strings.ToLower(text)
+	if charTokens(lang) {
+		var tokens []string
+		for _, r := range text {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				tokens = append(tokens, string(r))
+			}
+		}
+		return tokens
+	}
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// editDistance is the Levenshtein distance between two token sequences
+func editDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
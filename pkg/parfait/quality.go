@@ -0,0 +1,87 @@
+package parfait
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/go-audio/audio"
+)
+
+// Thresholds of the audio quality report
+const (
+	// clippedSampleLimit is the number of full-scale samples tolerated per slide
+	clippedSampleLimit = 3
+	// dcOffsetLimit is the largest tolerated mean sample value relative to full scale (-40 dBFS)
+	dcOffsetLimit = 0.01
+	// silentRMSLimit is the RMS below which a slide is considered silent (-60 dBFS)
+	silentRMSLimit = 0.001
+)
+
+// audioQuality is the analysis of one synthesized slide
+type audioQuality struct {
+	Clipped  int
+	DCOffset float64
+	RMS      float64
+}
+
+// analyzeAudio measures clipping, DC offset and RMS of buf relative to full scale
+func analyzeAudio(buf *audio.IntBuffer) audioQuality {
+	full := float64(int(1) << (buf.SourceBitDepth - 1))
+	var q audioQuality
+	if len(buf.Data) == 0 {
+		return q
+	}
+	var sum, squares float64
+	for _, v := range buf.Data {
+		x := float64(v)
+		if x >= full-1 || x <= -full {
+			q.Clipped++
+		}
+		sum += x
+		squares += x * x
+	}
+	n := float64(len(buf.Data))
+	q.DCOffset = sum / n / full
+	q.RMS = math.Sqrt(squares/n) / full
+	return q
+}
+
+// problems describes what is suspect about the slide's audio (nil = looks fine)
+func (q audioQuality) problems() []string {
+	var out []string
+	if q.RMS < silentRMSLimit {
+		out = append(out, "output is silent or nearly silent")
+	}
+	if q.Clipped >= clippedSampleLimit {
+		out = append(out, fmt.Sprintf("%d clipped samples", q.Clipped))
+	}
+	if math.Abs(q.DCOffset) > dcOffsetLimit {
+		out = append(out, fmt.Sprintf("DC offset of %.1f%%", q.DCOffset*100))
+	}
+	return out
+}
+
+// checkAudioQuality analyzes the WAV file of every synthesized slide and returns a warning
+// per suspect slide. Silence slides and slides that failed are skipped.
+func checkAudioQuality(outputDir string, notes []SlideNote, opts ttsOptions) []string {
+	var warnings []string
+	for _, note := range notes {
+		if note.Directives.Silence > 0 {
+			continue
+		}
+		data, err := os.ReadFile(slideOutputPath(outputDir, note.SlideNumber, opts))
+		if err != nil {
+			continue
+		}
+		buf, err := decodeWAV(data)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("slide %03d: %v", note.SlideNumber, err))
+			continue
+		}
+		for _, p := range analyzeAudio(buf).problems() {
+			warnings = append(warnings, fmt.Sprintf("slide %03d: %s", note.SlideNumber, p))
+		}
+	}
+	return warnings
+}
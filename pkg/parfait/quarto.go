@@ -0,0 +1,79 @@
+package parfait
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// quartoDivOpen matches a Pandoc fenced div such as `::: {.notes}`, `::: notes` or `:::: {.columns}`
+	quartoDivOpen  = regexp.MustCompile(`^:{3,}\s*(?:\{([^}]*)\}|([\w-]+))\s*:*\s*$`)
+	quartoDivClose = regexp.MustCompile(`^:{3,}\s*$`)
+	// quartoHeadingAttrs matches the attribute block of a heading, e.g. "## Results {.smaller}"
+	quartoHeadingAttrs = regexp.MustCompile(`\s*\{[^}]*\}\s*$`)
+)
+
+// isQuartoRevealFormat reports whether a headmatter `format:` value targets revealjs,
+// either `format: revealjs` or a map with a revealjs key
+func isQuartoRevealFormat(v any) bool {
+	switch f := v.(type) {
+	case string:
+		return f == "revealjs"
+	case map[string]any:
+		_, ok := f["revealjs"]
+		return ok
+	}
+	return false
+}
+
+// maskQuartoSource rewrites Quarto fenced divs without moving any byte: `::: {.notes}`
+// blocks become <!-- --> comments so they are read as narration, and the fences of
+// other divs (columns, incremental lists, ...) are blanked so their content stays
+// slide body.
+func maskQuartoSource(content []byte) []byte {
+	source := bytes.Clone(content)
+	lines := bytes.SplitAfter(source, []byte("\n"))
+
+	var open []bool // one entry per open div: whether it is a notes div
+	fence := ""
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(string(line))
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		text := strings.TrimRight(string(line), "\r\n")
+		switch {
+		case quartoDivClose.MatchString(text) && len(open) > 0:
+			notes := open[len(open)-1]
+			open = open[:len(open)-1]
+			blankLine(line)
+			if notes && !slices.Contains(open, true) {
+				copy(line, "-->")
+			}
+		case quartoDivOpen.MatchString(text):
+			m := quartoDivOpen.FindStringSubmatch(text)
+			notes := m[2] == "notes" || slices.Contains(strings.Fields(m[1]), ".notes")
+			blankLine(line)
+			if notes && !slices.Contains(open, true) {
+				copy(line, "<!--")
+			}
+			open = append(open, notes)
+		}
+	}
+	return source
+}
+
+// quartoSlideTitle drops the attribute block Quarto allows after a slide title
+func quartoSlideTitle(title string) string {
+	return quartoHeadingAttrs.ReplaceAllString(title, "")
+}
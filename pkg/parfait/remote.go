@@ -0,0 +1,97 @@
+package parfait
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// remoteDeckTimeout bounds the download of a deck given by URL
+	remoteDeckTimeout = 30 * time.Second
+	// maxRemoteDeckSize guards against downloading something that isn't a deck
+	maxRemoteDeckSize = 64 << 20
+)
+
+// isRemoteDeck reports whether the deck argument is an http(s) URL rather than a path
+func isRemoteDeck(arg string) bool {
+	u, err := url.Parse(arg)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// rawDeckURL turns a GitHub page link (github.com/<owner>/<repo>/blob/<ref>/<path>)
+// into the raw file URL; other URLs are returned unchanged
+func rawDeckURL(u *url.URL) *url.URL {
+	if u.Host != "github.com" {
+		return u
+	}
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 4)
+	if len(parts) < 4 || parts[2] != "blob" {
+		return u
+	}
+	raw := *u
+	raw.Host = "raw.githubusercontent.com"
+	raw.Path = "/" + parts[0] + "/" + parts[1] + "/" + parts[3]
+	raw.RawQuery = ""
+	return &raw
+}
+
+// downloadDeck fetches a deck into a temporary directory, keeping its file name so the
+// format is still detected from the extension. cleanup removes the directory.
+func downloadDeck(ctx context.Context, rawURL, proxyURL string) (string, func(), error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid deck URL: %v", err)
+	}
+	u = rawDeckURL(u)
+
+	ctx, cancel := context.WithTimeout(ctx, remoteDeckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid deck URL: %v", err)
+	}
+	resp, err := newHTTPClient(remoteDeckTimeout, proxyURL).Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to download deck: %v", redactErr(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("failed to download deck: %s returned status %d", redact(u.String()), resp.StatusCode)
+	}
+
+	name := path.Base(u.Path)
+	if !slices.Contains(deckExtensions, strings.ToLower(path.Ext(name))) {
+		name = "deck.md"
+	}
+	dir, err := os.MkdirTemp("", "parfait-deck-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteDeckSize+1))
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to download deck: %v", redactErr(err))
+	}
+	if len(body) > maxRemoteDeckSize {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to download deck: larger than %d MB", maxRemoteDeckSize>>20)
+	}
+	local := filepath.Join(dir, name)
+	if err := os.WriteFile(local, body, 0644); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	fmt.Printf("✓ Downloaded %s\n", redact(u.String()))
+	return local, cleanup, nil
+}
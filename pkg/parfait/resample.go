@@ -0,0 +1,80 @@
+package parfait
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-audio/audio"
+)
+
+// Range of --sample-rate
+const (
+	minSampleRate = 8000
+	maxSampleRate = 192000
+)
+
+// resampleTaps is the half-width of the interpolation kernel in zero crossings
+const resampleTaps = 16
+
+func validateSampleRate(rate int) error {
+	if rate != 0 && (rate < minSampleRate || rate > maxSampleRate) {
+		return fmt.Errorf("invalid sample rate: %d Hz. Use %d to %d, e.g. 48000", rate, minSampleRate, maxSampleRate)
+	}
+	return nil
+}
+
+// resample converts buf to rate with a Hann-windowed sinc kernel, low-pass filtering
+// below the new Nyquist frequency when downsampling. buf is returned as-is when it
+// already has the rate.
+func resample(buf *audio.IntBuffer, rate int) *audio.IntBuffer {
+	from := buf.Format.SampleRate
+	if rate == 0 || rate == from {
+		return buf
+	}
+	channels := buf.Format.NumChannels
+	frames := len(buf.Data) / channels
+	outFrames := int(int64(frames) * int64(rate) / int64(from))
+
+	out := &audio.IntBuffer{
+		Data:           make([]int, outFrames*channels),
+		Format:         &audio.Format{SampleRate: rate, NumChannels: channels},
+		SourceBitDepth: buf.SourceBitDepth,
+	}
+	maxValue := float64(int(1)<<(buf.SourceBitDepth-1) - 1)
+	minValue := -maxValue - 1
+
+	step := float64(from) / float64(rate)
+	cutoff := math.Min(1, 1/step)
+	width := float64(resampleTaps) / cutoff
+	for j := range outFrames {
+		t := float64(j) * step
+		lo := max(0, int(math.Ceil(t-width)))
+		hi := min(frames-1, int(math.Floor(t+width)))
+		for ch := range channels {
+			var sum, weights float64
+			for k := lo; k <= hi; k++ {
+				w := resampleKernel(t-float64(k), cutoff, width)
+				sum += w * float64(buf.Data[k*channels+ch])
+				weights += w
+			}
+			if weights != 0 {
+				sum /= weights
+			}
+			out.Data[j*channels+ch] = int(math.Round(math.Max(minValue, math.Min(maxValue, sum))))
+		}
+	}
+	return out
+}
+
+// resampleKernel is the windowed sinc weight of an input sample x frames away
+func resampleKernel(x, cutoff, width float64) float64 {
+	if math.Abs(x) >= width {
+		return 0
+	}
+	window := 0.5 + 0.5*math.Cos(math.Pi*x/width)
+	y := math.Pi * x * cutoff
+	if y == 0 {
+		return window
+	}
+	return window * math.Sin(y) / y
+}
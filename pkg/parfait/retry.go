@@ -0,0 +1,130 @@
+package parfait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// retryPolicy controls how failed provider requests are retried
+type retryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles on each further retry
+	BaseDelay time.Duration
+	// MaxDelay caps a single wait
+	MaxDelay time.Duration
+	// Jitter randomizes each wait by ±Jitter (0.2 = ±20%)
+	Jitter float64
+	// MaxElapsed stops retrying once this much time has passed (0 = no limit)
+	MaxElapsed time.Duration
+
+	// logf prints the retry messages, e.g. into a slide's ordered log (nil = stdout)
+	logf func(format string, args ...any)
+}
+
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+		MaxElapsed:  2 * time.Minute,
+	}
+}
+
+// validate reports invalid policy values
+func (p retryPolicy) validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("retry attempts must be at least 1, got %d", p.MaxAttempts)
+	}
+	if p.BaseDelay < 0 || p.MaxDelay < 0 || p.MaxElapsed < 0 {
+		return fmt.Errorf("retry delays must not be negative")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("retry jitter must be between 0 and 1, got %g", p.Jitter)
+	}
+	return nil
+}
+
+// backoff returns the wait before the given retry (1 = first retry)
+func (p retryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 && d > 0 {
+		delta := float64(d) * p.Jitter
+		d = time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+	}
+	return d
+}
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// nonRetryable wraps err so retryPolicy.do returns it immediately
+func nonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func (p retryPolicy) printf(format string, args ...any) {
+	if p.logf != nil {
+		p.logf(format, args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// do calls fn until it succeeds, returns a non-retryable error, or the policy is exhausted.
+// fn receives the 1-based attempt number.
+func (p retryPolicy) do(ctx context.Context, fn func(attempt int) error) error {
+	start := time.Now()
+	attempts := max(p.MaxAttempts, 1)
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			wait := p.backoff(attempt - 1)
+			if p.MaxElapsed > 0 && time.Since(start)+wait > p.MaxElapsed {
+				p.printf("  Giving up: retry time limit %s reached\n", p.MaxElapsed)
+				break
+			}
+			if wait > 0 {
+				p.printf("  Retrying in %s (attempt %d/%d)...\n", wait.Round(time.Millisecond), attempt, attempts)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = err
+	}
+	return lastErr
+}
@@ -0,0 +1,173 @@
+package parfait
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// revealSlideInfos reads the slides of a reveal.js HTML deck.
+// Each leaf <section> is a slide (a section holding sections is a vertical stack);
+// its <aside class="notes"> (or data-notes attribute) is the narration and
+// <!-- parfait: ... --> comments inside it are directives.
+func revealSlideInfos(content []byte) ([]slideInfo, error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	var slides []slideInfo
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Section && !hasChildSection(n) {
+			slides = append(slides, revealSlide(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if len(slides) == 0 {
+		return nil, fmt.Errorf("no reveal.js <section> slides found")
+	}
+	return slides, nil
+}
+
+// isRevealHTML reports whether content looks like an HTML document rather than markdown
+func isRevealHTML(content []byte) bool {
+	head := bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\ufeff")))
+	return hasPrefixFold(head, "<!doctype html") || hasPrefixFold(head, "<html")
+}
+
+func hasPrefixFold(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && strings.EqualFold(string(b[:len(prefix)]), prefix)
+}
+
+func hasChildSection(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Section {
+			return true
+		}
+	}
+	return false
+}
+
+func revealSlide(section *html.Node) slideInfo {
+	var slide slideInfo
+	if notes := htmlAttr(section, "data-notes"); strings.TrimSpace(notes) != "" {
+		slide.comments = append(slide.comments, strings.TrimSpace(notes))
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.CommentNode:
+			if comment := strings.TrimSpace(n.Data); isDirectiveComment(comment) {
+				slide.directives = append(slide.directives, comment)
+			}
+			return
+		case n.Type != html.ElementNode:
+			return
+		case n.DataAtom == atom.Aside && hasClass(n, "notes"):
+			if t := htmlText(n); t != "" {
+				slide.comments = append(slide.comments, t)
+			}
+			return
+		case n.DataAtom == atom.Script || n.DataAtom == atom.Style || n.DataAtom == atom.Pre:
+			// Code is not narrated
+			return
+		case n.DataAtom == atom.H1 || n.DataAtom == atom.H2:
+			if slide.title == "" {
+				slide.title = htmlText(n)
+			}
+		}
+		if isHTMLBlock(n) && !hasBlockChild(n) {
+			if t := htmlText(n); t != "" {
+				slide.body = append(slide.body, t)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for c := section.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	return slide
+}
+
+// htmlText returns the text of n, one line per block element or <br>
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if n.DataAtom == atom.Br {
+				b.WriteByte('\n')
+				return
+			}
+		default:
+			return
+		}
+		block := isHTMLBlock(n)
+		if block {
+			b.WriteByte('\n')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			b.WriteByte('\n')
+		}
+	}
+	walk(n)
+
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isHTMLBlock(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Div, atom.Li, atom.Ul, atom.Ol, atom.Blockquote, atom.Table, atom.Tr,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Figure, atom.Figcaption:
+		return true
+	}
+	return false
+}
+
+func hasBlockChild(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (isHTMLBlock(c) || c.DataAtom == atom.Aside || c.DataAtom == atom.Pre) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasClass(n *html.Node, class string) bool {
+	return slices.Contains(strings.Fields(htmlAttr(n, "class")), class)
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package parfait
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// audioPlayers are tried in order when PARFAIT_PLAYER is not set; the file is appended
+var audioPlayers = [][]string{
+	{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+	{"afplay"},
+	{"paplay"},
+	{"aplay", "-q"},
+}
+
+// audioPlayer returns the command that plays a file: PARFAIT_PLAYER (e.g. "mpv --no-video")
+// or the first installed player (nil = none)
+func audioPlayer() []string {
+	if env := strings.Fields(os.Getenv("PARFAIT_PLAYER")); len(env) > 0 {
+		return env
+	}
+	for _, p := range audioPlayers {
+		if _, err := exec.LookPath(p[0]); err == nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// checkReview fails early when --review cannot prompt
+func checkReview() error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("--review needs a terminal")
+	}
+	return nil
+}
+
+// reviewer plays each synthesized slide and asks whether to keep it
+type reviewer struct {
+	in         *bufio.Reader
+	player     []string
+	keyManager *APIKeyManager
+	outputDir  string
+	opts       ttsOptions
+}
+
+// reviewSlides lets the user accept, regenerate or re-word each slide in order. The slide's
+// file holds the accepted audio afterwards; edits are not written back to the deck.
+func reviewSlides(ctx context.Context, keyManager *APIKeyManager, notes []SlideNote, outputDir string, opts ttsOptions) error {
+	r := &reviewer{
+		in:         bufio.NewReader(os.Stdin),
+		player:     audioPlayer(),
+		keyManager: keyManager,
+		outputDir:  outputDir,
+		opts:       opts,
+	}
+	if r.player == nil {
+		fmt.Fprintln(os.Stderr, "Warning: no audio player found (set PARFAIT_PLAYER); open the files to listen")
+	}
+	for _, note := range notes {
+		if note.Directives.Silence > 0 || !statOK(slideOutputPath(outputDir, note.SlideNumber, opts)) {
+			continue
+		}
+		done, err := r.review(ctx, note)
+		if err != nil {
+			return err
+		}
+		if done {
+			fmt.Println("Accepting the remaining slides as they are")
+			break
+		}
+	}
+	return nil
+}
+
+// review prompts until the slide is accepted; done means the user stopped reviewing
+func (r *reviewer) review(ctx context.Context, note SlideNote) (done bool, err error) {
+	path := slideOutputPath(r.outputDir, note.SlideNumber, r.opts)
+	choices := "[a]ccept, [r]egenerate, [e]dit text, [p]lay again, [q]uit review"
+	if r.opts.Takes > 1 {
+		choices += fmt.Sprintf(", or take 1-%d", r.opts.Takes)
+	}
+	r.play(path)
+	for {
+		fmt.Fprintf(os.Stderr, "Slide %03d %q: %s: ", note.SlideNumber, note.displayTitle(), choices)
+		answer, err := r.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "a", "":
+			return false, nil
+		case "q":
+			return true, nil
+		case "p":
+			r.play(path)
+		case "r":
+			if r.regenerate(ctx, note, path) {
+				r.play(path)
+			}
+		case "e":
+			fmt.Fprintf(os.Stderr, "Current text: %s\nNew text (empty keeps it): ", note.Note)
+			text, err := r.readLine()
+			if err != nil {
+				return false, err
+			}
+			if text == "" {
+				continue
+			}
+			note.Note, note.Segments, note.spoken = text, nil, nil
+			if r.regenerate(ctx, note, path) {
+				r.play(path)
+			}
+		default:
+			take, convErr := strconv.Atoi(answer)
+			if convErr != nil || take < 1 || take > r.opts.Takes {
+				fmt.Fprintf(os.Stderr, "Unknown choice %q\n", answer)
+				continue
+			}
+			if err := copyFile(takePath(path, take), path); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to use take %d: %v\n", take, err)
+				continue
+			}
+			r.play(path)
+		}
+	}
+}
+
+// regenerate synthesizes the slide again into its file, bypassing the cache so it is a new
+// read, and reports whether it succeeded
+func (r *reviewer) regenerate(ctx context.Context, note SlideNote, path string) bool {
+	opts := note.Directives.applyTo(r.opts)
+	opts.Cache = ttsCache{}
+	if err := synthesizeSlide(ctx, r.keyManager, note, path, opts.UseGemini, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to regenerate slide %03d: %v\n", note.SlideNumber, redactErr(err))
+		return false
+	}
+	return true
+}
+
+// play plays a file and waits for it to finish; without a player the path is shown instead
+func (r *reviewer) play(path string) {
+	if r.player == nil {
+		fmt.Fprintf(os.Stderr, "Listen to %s\n", path)
+		return
+	}
+	cmd := exec.Command(r.player[0], append(r.player[1:], path)...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to play %s: %v\n", path, err)
+	}
+}
+
+func (r *reviewer) readLine() (string, error) {
+	line, err := r.in.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,91 @@
+package parfait
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// slideLog keeps the output of parallel slides in slide order. The earliest unfinished
+// slide prints as it goes; later slides are buffered and flushed once every slide
+// before them has finished.
+type slideLog struct {
+	mu    sync.Mutex
+	order []int
+	// head indexes order: the slide currently printing live
+	head     int
+	done     map[int]bool
+	buffered map[int][]logLine
+}
+
+// logLine is one buffered write and whether it goes to stderr
+type logLine struct {
+	stderr bool
+	text   string
+}
+
+func newSlideLog(notes []SlideNote) *slideLog {
+	l := &slideLog{done: make(map[int]bool), buffered: make(map[int][]logLine)}
+	for _, note := range notes {
+		l.order = append(l.order, note.SlideNumber)
+	}
+	return l
+}
+
+// write prints text for a slide now if it is the head, or buffers it
+func (l *slideLog) write(slide int, stderr bool, text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.head < len(l.order) && l.order[l.head] != slide {
+		l.buffered[slide] = append(l.buffered[slide], logLine{stderr, text})
+		return
+	}
+	printLogLine(logLine{stderr, text})
+}
+
+// finish marks a slide as done and flushes the slides that are now next in order
+func (l *slideLog) finish(slide int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.done[slide] = true
+	for l.head < len(l.order) && l.done[l.order[l.head]] {
+		l.head++
+		if l.head == len(l.order) {
+			break
+		}
+		next := l.order[l.head]
+		for _, line := range l.buffered[next] {
+			printLogLine(line)
+		}
+		delete(l.buffered, next)
+	}
+}
+
+func printLogLine(line logLine) {
+	var w io.Writer = os.Stdout
+	if line.stderr {
+		w = os.Stderr
+	}
+	fmt.Fprint(w, line.text)
+}
+
+// logf prints a progress line of the slide being synthesized, in slide order when
+// slides run in parallel
+func (o ttsOptions) logf(format string, args ...any) {
+	o.logTo(false, format, args...)
+}
+
+// warnf is logf for warnings on stderr
+func (o ttsOptions) warnf(format string, args ...any) {
+	o.logTo(true, format, args...)
+}
+
+func (o ttsOptions) logTo(stderr bool, format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	if o.log == nil {
+		printLogLine(logLine{stderr, text})
+		return
+	}
+	o.log.write(o.logSlide, stderr, text)
+}
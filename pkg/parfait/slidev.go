@@ -0,0 +1,96 @@
+package parfait
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// slidevHeadmatterKeys only appear in Slidev headmatter, so they identify a Slidev deck
+var slidevHeadmatterKeys = []string{
+	"layout", "transition", "highlighter", "drawings", "mdc", "lineNumbers",
+	"colorSchema", "routerMode", "monaco", "exportFilename", "seoMeta",
+}
+
+var thematicBreakPattern = regexp.MustCompile(`^ {0,3}([-*_])[ \t]*(?:[-*_][ \t]*){2,}$`)
+
+// maskSlidevSource rewrites a Slidev deck so the Marp splitter reads it correctly,
+// without moving any byte. In Slidev a `---` separator may be followed by a per-slide
+// YAML block closed by another `---`; parsed as markdown that block becomes a setext
+// heading or an extra slide. Headmatter and per-slide frontmatter are blanked out,
+// separators become `***` (which can't underline a heading) and other rules are blanked
+// since they don't start a slide in Slidev.
+func maskSlidevSource(content []byte) []byte {
+	source := bytes.Clone(content)
+	lines := bytes.SplitAfter(source, []byte("\n"))
+
+	blank := func(from, to int) {
+		for _, line := range lines[from:to] {
+			blankLine(line)
+		}
+	}
+
+	i := 0
+	if len(lines) > 0 && isSlidevSeparator(lines[0]) {
+		if end := slidevFrontmatterEnd(lines, 1); end > 0 {
+			blank(0, end+1)
+			i = end + 1
+		}
+	}
+
+	fence := ""
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(string(lines[i]))
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		switch {
+		case isSlidevSeparator(lines[i]):
+			copy(lines[i], "***")
+			if end := slidevFrontmatterEnd(lines, i+1); end > 0 {
+				blank(i+1, end+1)
+				i = end
+			}
+		case thematicBreakPattern.MatchString(strings.TrimRight(string(lines[i]), "\r\n")):
+			blank(i, i+1)
+		}
+	}
+	return source
+}
+
+func isSlidevSeparator(line []byte) bool {
+	return string(bytes.TrimRight(line, " \t\r\n")) == "---"
+}
+
+// slidevFrontmatterEnd returns the index of the `---` closing a YAML block that starts at
+// lines[start], or -1 if the lines there are slide content rather than frontmatter
+func slidevFrontmatterEnd(lines [][]byte, start int) int {
+	var block bytes.Buffer
+	for j := start; j < len(lines); j++ {
+		if isSlidevSeparator(lines[j]) {
+			if j == start {
+				return -1
+			}
+			var meta map[string]any
+			if err := yaml.Unmarshal(block.Bytes(), &meta); err != nil || len(meta) == 0 {
+				return -1
+			}
+			return j
+		}
+		if len(bytes.TrimSpace(lines[j])) == 0 {
+			// Frontmatter starts right after the separator and has no blank lines
+			return -1
+		}
+		block.Write(lines[j])
+	}
+	return -1
+}
@@ -0,0 +1,28 @@
+package parfait
+
+import "strings"
+
+// speechText prepares one piece of narration for the provider
+func speechText(text string, opts ttsOptions) string {
+	if opts.StripMarkdown {
+		text = markdownToPlain(text)
+	}
+	text = applyURLPolicy(text, opts.URLPolicy, opts.Language)
+	text = applyEmojiPolicy(text, opts.EmojiPolicy, opts.Language)
+	text = opts.Lexicon.apply(text)
+	text = opts.Acronyms.apply(text)
+	if opts.Normalize {
+		text = normalizeText(text, opts.Language)
+	}
+	return text
+}
+
+// spokenText is the whole narration of a slide as sent to the provider, without pauses,
+// e.g. to compare with a transcript of its audio
+func (n SlideNote) spokenText(opts ttsOptions) string {
+	var texts []string
+	for _, segment := range n.speechSegments() {
+		texts = append(texts, speechText(stripPauseMarkers(segment), opts))
+	}
+	return strings.Join(texts, " ")
+}
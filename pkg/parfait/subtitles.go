@@ -0,0 +1,218 @@
+package parfait
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Values of --subtitles
+const (
+	subtitleFormatSRT = "srt"
+	subtitleFormatVTT = "vtt"
+)
+
+var subtitleFormats = []string{subtitleFormatSRT, subtitleFormatVTT}
+
+// Cue size limits: two lines of about 42 characters, the common broadcast guideline
+const (
+	maxCueChars  = 84
+	maxLineChars = 42
+)
+
+// subtitleCue is one caption shown from Start to End
+type subtitleCue struct {
+	Start, End time.Duration
+	Text       string
+}
+
+// cueText is a caption-sized piece of a slide's narration: the plain text shown and the
+// text sent to the provider for it, which is what alignment compares with the transcript
+type cueText struct {
+	shown, spoken string
+}
+
+// cueTexts splits a slide's narration into cues at sentence boundaries. Markdown is shown
+// as the words it displays and reading annotations as their base text; the spoken side
+// goes through speechText like spokenText, so together they read as the slide's
+// spokenText.
+func (n SlideNote) cueTexts(opts ttsOptions) []cueText {
+	annotated := n.spoken
+	if len(annotated) == 0 {
+		annotated = n.segments()
+	}
+	var texts []cueText
+	for _, segment := range annotated {
+		for _, chunk := range chunkText(stripPauseMarkers(segment), maxCueChars) {
+			shown := strings.Join(strings.Fields(markdownToPlain(rubyBase(chunk))), " ")
+			if shown == "" {
+				continue
+			}
+			texts = append(texts, cueText{shown: shown, spoken: speechText(rubyReading(chunk), opts)})
+		}
+	}
+	return texts
+}
+
+func validateSubtitleFormat(format string) error {
+	switch format {
+	case "", subtitleFormatSRT, subtitleFormatVTT:
+		return nil
+	}
+	return fmt.Errorf("invalid subtitle format: %q. Use %s", format, strings.Join(subtitleFormats, ", "))
+}
+
+// subtitlesPath names the captions after the deck audio track: audio-<lang>.<format>
+func subtitlesPath(outputDir, format string, opts ttsOptions) string {
+	return filepath.Join(outputDir, fmt.Sprintf("audio-%s.%s", opts.Language, format))
+}
+
+// subtitleCues times each slide's note text across its narration. Notes are split into cues
+// (see cueTexts) and each cue gets a share of the speech, between the slide's leading
+// and trailing silence, proportional to its length. Slides with words from alignNarration
+// are retimed to when each cue is actually heard.
+func subtitleCues(notes []SlideNote, timings []slideTiming, words map[int][]timedWord, opts ttsOptions) []subtitleCue {
+	byNumber := make(map[int]SlideNote, len(notes))
+	for _, note := range notes {
+		byNumber[note.SlideNumber] = note
+	}
+	var cues []subtitleCue
+	for _, t := range timings {
+		note, ok := byNumber[t.Slide]
+		if !ok || note.Directives.Silence > 0 {
+			continue
+		}
+		slideOpts := note.Directives.applyTo(opts)
+		start := secondsDuration(t.Start)
+		length := secondsDuration(t.Duration)
+		if speech := length - slideOpts.LeadingSilence - slideOpts.Silence; speech > 0 {
+			start += slideOpts.LeadingSilence
+			length = speech
+		}
+
+		texts := note.cueTexts(slideOpts)
+		total := 0
+		for _, text := range texts {
+			total += cueWeight(text.shown)
+		}
+		if total == 0 {
+			continue
+		}
+		done := 0
+		slideCues := make([]subtitleCue, 0, len(texts))
+		for _, text := range texts {
+			from := start + length*time.Duration(done)/time.Duration(total)
+			done += cueWeight(text.shown)
+			to := start + length*time.Duration(done)/time.Duration(total)
+			slideCues = append(slideCues, subtitleCue{Start: from, End: to, Text: wrapCue(text.shown)})
+		}
+		if w := words[t.Slide]; len(w) > 0 {
+			alignCues(slideCues, texts, w, secondsDuration(t.Start), slideOpts.Language)
+		}
+		cues = append(cues, slideCues...)
+	}
+	return cues
+}
+
+// cueWeight approximates how long a cue takes to say by its non-space characters
+func cueWeight(text string) int {
+	n := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// wrapCue breaks a long cue into two lines of similar length
+func wrapCue(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	n := utf8.RuneCountInString(text)
+	if n <= maxLineChars {
+		return text
+	}
+	head, rest := splitAtRune(text, (n+1)/2)
+	return strings.TrimSpace(head) + "\n" + strings.TrimSpace(rest)
+}
+
+// formatSRT renders cues as a SubRip file
+func formatSRT(cues []subtitleCue) string {
+	var b strings.Builder
+	for i, c := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, srtTime(c.Start), srtTime(c.End), c.Text)
+	}
+	return b.String()
+}
+
+// vttEscaper escapes the characters WebVTT cue text reserves for markup
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// formatVTT renders cues as a WebVTT file
+func formatVTT(cues []subtitleCue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, c := range cues {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", vttTime(c.Start), vttTime(c.End), vttEscaper.Replace(c.Text))
+	}
+	return b.String()
+}
+
+// chapterCues is one cue per slide, named after its title, for a WebVTT chapters track
+func chapterCues(notes []SlideNote, timings []slideTiming) []subtitleCue {
+	titles := make(map[int]string, len(notes))
+	for _, note := range notes {
+		titles[note.SlideNumber] = note.displayTitle()
+	}
+	cues := make([]subtitleCue, 0, len(timings))
+	for _, t := range timings {
+		start := secondsDuration(t.Start)
+		cues = append(cues, subtitleCue{Start: start, End: start + secondsDuration(t.Duration), Text: titles[t.Slide]})
+	}
+	return cues
+}
+
+// srtTime formats d as HH:MM:SS,mmm
+func srtTime(d time.Duration) string {
+	return strings.Replace(vttTime(d), ".", ",", 1)
+}
+
+// vttTime formats d as HH:MM:SS.mmm
+func vttTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// secondsDuration converts seconds from timings.json back to a duration
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// writeSubtitles writes the captions of the deck in the given format; WebVTT captions come
+// with a chapters track, audio-<lang>.chapters.vtt
+func writeSubtitles(notes []SlideNote, timings []slideTiming, words map[int][]timedWord, outputDir, format string, opts ttsOptions) error {
+	cues := subtitleCues(notes, timings, words, opts)
+	path := subtitlesPath(outputDir, format, opts)
+	content := formatSRT(cues)
+	if format == subtitleFormatVTT {
+		content = formatVTT(cues)
+	}
+	if err := writeFileAtomic(path, []byte(content)); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote %d subtitle cues to %s\n", len(cues), path)
+
+	if format != subtitleFormatVTT {
+		return nil
+	}
+	chapters := chapterCues(notes, timings)
+	path = subtitlesPath(outputDir, "chapters."+subtitleFormatVTT, opts)
+	if err := writeFileAtomic(path, []byte(formatVTT(chapters))); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote %d chapters to %s\n", len(chapters), path)
+	return nil
+}
@@ -0,0 +1,48 @@
+package parfait
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// maxTakes bounds --takes, since every take costs a full synthesis of the slide
+const maxTakes = 10
+
+// takePath is the file of one take of a slide: 007.wav becomes 007.take2.wav
+func takePath(outputPath string, take int) string {
+	ext := filepath.Ext(outputPath)
+	return fmt.Sprintf("%s.take%d%s", strings.TrimSuffix(outputPath, ext), take, ext)
+}
+
+// hasTakes reports whether an earlier run left take files for the slide
+func hasTakes(outputPath string) bool {
+	for take := 1; take <= maxTakes; take++ {
+		if statOK(takePath(outputPath, take)) {
+			return true
+		}
+	}
+	return false
+}
+
+// synthesizeTakes synthesizes a slide opts.Takes times into its take files and uses the
+// first take as the slide's audio; with one take it is synthesizeSlide. Only the first
+// take uses the cache, so every run offers fresh alternatives.
+func synthesizeTakes(ctx context.Context, keyManager *APIKeyManager, note SlideNote, outputPath string, useGemini bool, opts ttsOptions) error {
+	if opts.Takes <= 1 {
+		return synthesizeSlide(ctx, keyManager, note, outputPath, useGemini, opts)
+	}
+	for take := 1; take <= opts.Takes; take++ {
+		opts.logf("  Slide %03d take %d/%d\n", note.SlideNumber, take, opts.Takes)
+		takeOpts := opts
+		if take > 1 {
+			// Later takes are new reads on every run, not cache hits of an earlier one
+			takeOpts.Cache = ttsCache{}
+		}
+		if err := synthesizeSlide(ctx, keyManager, note, takePath(outputPath, take), useGemini, takeOpts); err != nil {
+			return fmt.Errorf("take %d: %w", take, err)
+		}
+	}
+	return copyFile(takePath(outputPath, 1), outputPath)
+}
@@ -0,0 +1,113 @@
+package parfait
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-audio/audio"
+)
+
+// Range of --speed
+const (
+	minTempo = 0.5
+	maxTempo = 2.0
+)
+
+// WSOLA parameters: frame length and how far the splice point may move to stay in phase
+const (
+	tempoFrame     = 0.030 // seconds
+	tempoTolerance = 0.010 // seconds
+)
+
+func validateTempo(tempo float64) error {
+	if tempo < minTempo || tempo > maxTempo {
+		return fmt.Errorf("invalid speed: %g. Use %g to %g, e.g. 1.1", tempo, minTempo, maxTempo)
+	}
+	return nil
+}
+
+// changeTempo speeds buf up (tempo > 1) or slows it down without changing pitch, using
+// waveform-similarity overlap-add (WSOLA). tempo 0 or 1 returns buf as-is.
+func changeTempo(buf *audio.IntBuffer, tempo float64) *audio.IntBuffer {
+	if tempo == 0 || tempo == 1 {
+		return buf
+	}
+	channels := buf.Format.NumChannels
+	frames := len(buf.Data) / channels
+	n := int(tempoFrame * float64(buf.Format.SampleRate))
+	tolerance := int(tempoTolerance * float64(buf.Format.SampleRate))
+	if frames < 2*n {
+		return buf
+	}
+	hopOut := n / 2
+	hopIn := float64(hopOut) * tempo
+
+	// Mono mixdown used to find the best splice point
+	mono := make([]float64, frames)
+	for i := range frames {
+		for ch := range channels {
+			mono[i] += float64(buf.Data[i*channels+ch])
+		}
+	}
+
+	// Periodic Hann window: overlapping halves sum to one
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+
+	outFrames := int(float64(frames) / tempo)
+	out := make([]float64, (outFrames+n)*channels)
+	prev := 0
+	for k := 0; ; k++ {
+		nominal := int(float64(k) * hopIn)
+		if nominal+n > frames {
+			break
+		}
+		pos := nominal
+		if k > 0 {
+			pos = bestSplice(mono, prev+hopOut, nominal, tolerance, n)
+		}
+		start := k * hopOut
+		for i := range n {
+			for ch := range channels {
+				out[(start+i)*channels+ch] += window[i] * float64(buf.Data[(pos+i)*channels+ch])
+			}
+		}
+		prev = pos
+	}
+
+	maxValue := float64(int(1)<<(buf.SourceBitDepth-1) - 1)
+	minValue := -maxValue - 1
+	result := &audio.IntBuffer{
+		Data:           make([]int, outFrames*channels),
+		Format:         &audio.Format{SampleRate: buf.Format.SampleRate, NumChannels: channels},
+		SourceBitDepth: buf.SourceBitDepth,
+	}
+	for i := range result.Data {
+		result.Data[i] = int(math.Round(math.Max(minValue, math.Min(maxValue, out[i]))))
+	}
+	return result
+}
+
+// bestSplice returns the start near nominal whose frame best matches the natural
+// continuation of the previous frame at template
+func bestSplice(mono []float64, template, nominal, tolerance, n int) int {
+	if template+n > len(mono) {
+		return nominal
+	}
+	best, bestScore := nominal, math.Inf(-1)
+	lo := max(0, nominal-tolerance)
+	hi := min(len(mono)-n, nominal+tolerance)
+	for pos := lo; pos <= hi; pos++ {
+		var score float64
+		// Every fourth sample is enough to find the phase
+		for i := 0; i < n; i += 4 {
+			score += mono[template+i] * mono[pos+i]
+		}
+		if score > bestScore {
+			best, bestScore = pos, score
+		}
+	}
+	return best
+}
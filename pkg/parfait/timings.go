@@ -0,0 +1,95 @@
+package parfait
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// timingsFileName is the per-slide timing manifest written next to the audio
+const timingsFileName = "timings.json"
+
+// slideTiming is a slide's entry in timings.json; times are in seconds
+type slideTiming struct {
+	Slide    int     `json:"slide"`
+	Title    string  `json:"title,omitempty"`
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+	// Path is the slide's audio file relative to the manifest
+	Path string `json:"path"`
+}
+
+// timingManifest is the content of timings.json
+type timingManifest struct {
+	Total     float64       `json:"total"`
+	DeckAudio string        `json:"deck_audio,omitempty"`
+	Slides    []slideTiming `json:"slides"`
+}
+
+// measureTimings reads the length of every slide's WAV file from its header; slides are placed back to
+// back in slide order from offset, overlapping by the crossfade, as in the deck audio. Slides without a
+// file are left out.
+func measureTimings(notes []SlideNote, outputDir string, offset time.Duration, opts ttsOptions) ([]slideTiming, error) {
+	var timings []slideTiming
+	position := offset
+	for _, note := range notes {
+		info, err := readWAVInfo(slideOutputPath(outputDir, note.SlideNumber, opts))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("slide %03d: %v", note.SlideNumber, err)
+		}
+		length := info.Duration()
+		position -= min(opts.Crossfade, position-offset, length)
+		path, err := filepath.Rel(outputDir, slideAudioPath(outputDir, note.SlideNumber, opts))
+		if err != nil {
+			return nil, err
+		}
+		timings = append(timings, slideTiming{
+			Slide:    note.SlideNumber,
+			Title:    note.Title,
+			Start:    seconds(position),
+			Duration: seconds(length),
+			Path:     filepath.ToSlash(path),
+		})
+		position += length
+	}
+	return timings, nil
+}
+
+// writeTimings writes timings.json to outputDir
+func writeTimings(outputDir string, timings []slideTiming, opts ttsOptions) error {
+	manifest := timingManifest{Slides: timings}
+	if n := len(timings); n > 0 {
+		manifest.Total = math.Round((timings[n-1].Start+timings[n-1].Duration)*1000) / 1000
+	}
+	if opts.DeckAudio {
+		if deck := deckAudioPath(outputDir, opts); statOK(deck) {
+			manifest.DeckAudio = filepath.Base(deck)
+		}
+	}
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(outputDir, timingsFileName)
+	if err := writeFileAtomic(path, append(b, '\n')); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote slide timings to %s\n", path)
+	return nil
+}
+
+func statOK(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// seconds rounds d to milliseconds
+func seconds(d time.Duration) float64 {
+	return float64(d.Milliseconds()) / 1000
+}
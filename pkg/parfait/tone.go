@@ -0,0 +1,74 @@
+package parfait
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tonePreset bundles narration settings that work well together for a kind of deck
+type tonePreset struct {
+	// GeminiVoice is the prebuilt Gemini voice used unless a voice is set explicitly
+	GeminiVoice string
+	// Style is a delivery direction prepended to Gemini prompts
+	Style string
+	// Speed is the KokoVox speaking rate (1 = normal)
+	Speed float64
+	// Silence is the pause after each slide
+	Silence time.Duration
+}
+
+var tonePresets = map[string]tonePreset{
+	"lecture": {
+		GeminiVoice: "Charon",
+		Style:       "Read this as a university lecturer: calm, clear and measured, pausing briefly between ideas",
+		Speed:       0.95,
+		Silence:     1500 * time.Millisecond,
+	},
+	"marketing": {
+		GeminiVoice: "Puck",
+		Style:       "Read this as an upbeat product presenter: energetic, warm and confident",
+		Speed:       1.05,
+		Silence:     700 * time.Millisecond,
+	},
+	"tutorial": {
+		GeminiVoice: "Achird",
+		Style:       "Read this as a friendly instructor walking someone through the steps, slowly and patiently",
+		Speed:       0.9,
+		Silence:     2 * time.Second,
+	},
+}
+
+func toneNames() []string {
+	names := make([]string, 0, len(tonePresets))
+	for k := range tonePresets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateTone reports unknown preset names; empty means no preset
+func validateTone(name string) error {
+	if _, ok := tonePresets[name]; name != "" && !ok {
+		return fmt.Errorf("invalid tone: %q. Use %s", name, strings.Join(toneNames(), ", "))
+	}
+	return nil
+}
+
+// applyTone sets the preset's voice, style, pacing and pauses; more specific settings applied later win
+func applyTone(opts *ttsOptions, name string) {
+	preset, ok := tonePresets[name]
+	if !ok {
+		return
+	}
+	opts.Voice = preset.GeminiVoice
+	if !opts.UseGemini {
+		// Gemini voice names mean nothing to KokoVox, which keeps its default voice
+		opts.Voice = ""
+	}
+	opts.Style = preset.Style
+	opts.Speed = preset.Speed
+	opts.Silence = preset.Silence
+}
@@ -0,0 +1,1175 @@
+package parfait
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/yuin/goldmark/ast"
+	"google.golang.org/genai"
+)
+
+// APIKeyManager manages rotation of multiple API keys
+type APIKeyManager struct {
+	mu    sync.Mutex
+	keys  []string
+	index int
+}
+
+// NewAPIKeyManager creates a new API key manager with rotation
+func NewAPIKeyManager() (*APIKeyManager, error) {
+	keys := googleAPIKeysFromEnv()
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no API keys found. Set GOOGLE_API_KEY, GOOGLE_API_KEYS (comma-separated) or GOOGLE_API_KEY_1, GOOGLE_API_KEY_2, etc")
+	}
+	for _, key := range keys {
+		registerSecret(key)
+	}
+
+	fmt.Printf("Loaded %d API key(s) for rotation\n", len(keys))
+	return &APIKeyManager{keys: keys, index: 0}, nil
+}
+
+// googleAPIKeysFromEnv merges every supported key source into one deduplicated list:
+// GOOGLE_API_KEY_<n> in numeric order, then GOOGLE_API_KEYS (comma-separated), then GOOGLE_API_KEY.
+// There is no upper bound on the number of keys.
+func googleAPIKeysFromEnv() []string {
+	type numbered struct {
+		n   int
+		key string
+	}
+	var indexed []numbered
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, "GOOGLE_API_KEY_") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(name, "GOOGLE_API_KEY_"))
+		if err != nil || n < 1 {
+			continue
+		}
+		indexed = append(indexed, numbered{n, value})
+	}
+	sort.Slice(indexed, func(i, j int) bool { return indexed[i].n < indexed[j].n })
+
+	var keys []string
+	for _, k := range indexed {
+		keys = append(keys, k.key)
+	}
+	keys = append(keys, strings.Split(os.Getenv("GOOGLE_API_KEYS"), ",")...)
+	keys = append(keys, os.Getenv("GOOGLE_API_KEY"))
+	return normalizeKeys(keys)
+}
+
+// NextKey returns the next API key in rotation and its 1-based index.
+func (m *APIKeyManager) NextKey() (string, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := m.keys[m.index]
+	keyIndex := m.index + 1
+	m.index = (m.index + 1) % len(m.keys)
+	return key, keyIndex
+}
+
+// KeyCount returns number of available keys. Keys are immutable after construction.
+func (m *APIKeyManager) KeyCount() int {
+	return len(m.keys)
+}
+
+// GetAllKeys returns all available API keys for retry logic (copy).
+func (m *APIKeyManager) GetAllKeys() []string {
+	out := make([]string, len(m.keys))
+	copy(out, m.keys)
+	return out
+}
+
+const defaultKokoVoxURL = "http://localhost:5108"
+const defaultTTSConcurrency = 3
+const defaultGeminiVoice = "Iapetus"
+
+const geminiTTSModel = "gemini-2.5-flash-preview-tts"
+
+// defaultSegmentPause separates the comments of a slide with several
+const defaultSegmentPause = 500 * time.Millisecond
+
+// getKokoVoxURL returns the KokoVox service URL from environment or default
+func getKokoVoxURL() string {
+	if url := os.Getenv("KOKOVOX_URL"); url != "" {
+		return url
+	}
+	return defaultKokoVoxURL
+}
+
+// writeWAVFile saves raw PCM bytes as a WAV file with the given silence added at the end
+func writeWAVFile(filename string, pcmData []byte, channels, sampleRate, bitsPerSample int, silence time.Duration, meta riffInfo) error {
+	buf := pcmToBuffer(pcmData, channels, sampleRate, bitsPerSample)
+	appendSilence(buf, silence)
+	return writeWAVBuffer(filename, buf, meta)
+}
+
+// checkKokoVoxHealth checks if KokoVox service is available and supports the requested language
+func checkKokoVoxHealth(language string, timeout time.Duration, proxyURL string) error {
+	kokovoxURL := strings.TrimRight(getKokoVoxURL(), "/")
+	u, err := url.Parse(kokovoxURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid KOKOVOX_URL: %s", redact(kokovoxURL))
+	}
+	if u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+		fmt.Fprintf(os.Stderr, "Warning: KokoVox at %s is remote but not using HTTPS; notes are sent in plaintext\n", redact(kokovoxURL))
+	}
+
+	healthURL := fmt.Sprintf("%s/health", kokovoxURL)
+	client := newHTTPClient(timeout, proxyURL)
+	resp, err := client.Get(healthURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to KokoVox service at %s: %v", redact(kokovoxURL), redactErr(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("KokoVox service at %s returned status %d", redact(kokovoxURL), resp.StatusCode)
+	}
+
+	fmt.Printf("✓ KokoVox service is available at %s\n", redact(kokovoxURL))
+
+	// The handshake was already verified by the client; report what was negotiated
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+		fmt.Printf("  TLS %s, certificate for %s valid until %s\n", tls.VersionName(resp.TLS.Version), cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+		if time.Until(cert.NotAfter) < 14*24*time.Hour {
+			fmt.Fprintf(os.Stderr, "Warning: KokoVox TLS certificate expires on %s\n", cert.NotAfter.Format("2006-01-02"))
+		}
+	}
+
+	info, err := fetchKokoVoxInfo(client, kokovoxURL)
+	if err != nil {
+		// Older KokoVox builds have no info endpoint; the health check alone is enough to proceed
+		fmt.Printf("  (server info unavailable: %v)\n", redactErr(err))
+		if !languageSupported(language, kokoVoxDefaultLanguages) {
+			return fmt.Errorf("KokoVox at %s does not report its languages; only %s are assumed, got %q", redact(kokovoxURL), strings.Join(kokoVoxDefaultLanguages, ", "), language)
+		}
+		return nil
+	}
+	if info.Version != "" {
+		fmt.Printf("  KokoVox version: %s\n", info.Version)
+	}
+	if len(info.Voices) > 0 {
+		fmt.Printf("  Voices: %s\n", strings.Join(info.Voices, ", "))
+	}
+	if len(info.Languages) > 0 && !languageSupported(language, info.Languages) {
+		return fmt.Errorf("KokoVox at %s does not support language %q (available: %s)", redact(kokovoxURL), language, strings.Join(info.Languages, ", "))
+	}
+	return nil
+}
+
+// kokoVoxInfo is the response of KokoVox's /info endpoint
+type kokoVoxInfo struct {
+	Version   string   `json:"version"`
+	Languages []string `json:"languages"`
+	Voices    []string `json:"voices"`
+}
+
+// fetchKokoVoxInfo queries the server's version and capabilities
+func fetchKokoVoxInfo(client *http.Client, kokovoxURL string) (kokoVoxInfo, error) {
+	resp, err := client.Get(fmt.Sprintf("%s/info", kokovoxURL))
+	if err != nil {
+		return kokoVoxInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return kokoVoxInfo{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var info kokoVoxInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return kokoVoxInfo{}, fmt.Errorf("invalid info response: %v", err)
+	}
+	return info, nil
+}
+
+// isLoopbackHost reports whether host refers to the local machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// generateLocalTTS generates TTS using local TTS service (KokoVox), copying the WAV response
+// to w as it arrives
+func generateLocalTTS(ctx context.Context, text string, opts ttsOptions, w io.Writer) error {
+	baseURL := getKokoVoxURL()
+
+	// Prepare request body
+	requestBody := map[string]interface{}{
+		"language": kokoVoxLanguage(opts.Language),
+		"text":     text,
+	}
+	if opts.Voice != "" {
+		requestBody["voice"] = opts.Voice
+	}
+	if opts.Speed > 0 {
+		requestBody["speed"] = opts.Speed
+	}
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	// Make HTTP request (bounded by the per-request TTS timeout)
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(opts.Timeout, defaultTTSTimeout))
+	defer cancel()
+	apiURL := fmt.Sprintf("%s/v1/audio/speech", baseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := newHTTPClient(0, opts.Proxy)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call TTS API: %w", redactErr(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &httpStatusError{StatusCode: resp.StatusCode, Body: redact(string(bodyBytes))}
+	}
+
+	// Stream audio data so long narration is never held in memory as a whole
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read audio data: %v", err)
+	}
+	return nil
+}
+
+// SlideNote represents a slide's note content
+type SlideNote struct {
+	SlideNumber int    `json:"slide"`
+	Title       string `json:"title,omitempty"`
+	Note        string `json:"note"`
+	// Segments holds each comment of a slide with several, synthesized separately
+	Segments []string `json:"segments,omitempty"`
+	// Directives holds per-slide overrides from <!-- parfait: ... --> comments
+	Directives slideDirectives `json:"directives,omitzero"`
+
+	// spoken holds the segments with their reading annotations; Note and Segments have them stripped
+	spoken []string
+}
+
+// segments returns the pieces of narration to synthesize separately
+func (n SlideNote) segments() []string {
+	if len(n.Segments) > 0 {
+		return n.Segments
+	}
+	return []string{n.Note}
+}
+
+// speechSegments returns the segments as sent to the provider, with annotated readings applied
+func (n SlideNote) speechSegments() []string {
+	if len(n.spoken) == 0 {
+		return n.segments()
+	}
+	out := make([]string, len(n.spoken))
+	for i, s := range n.spoken {
+		out[i] = rubyReading(s)
+	}
+	return out
+}
+
+// slideInfo holds parsed information for a single slide
+type slideInfo struct {
+	title      string
+	comments   []string
+	directives []string
+	// body is the visible text of the slide, used when it has no comment
+	body []string
+	// first is the slide's first block, locating the slide in the source
+	first ast.Node
+}
+
+// addBody records the visible text of a block; code is not narrated
+func (s *slideInfo) addBody(n ast.Node, source []byte) {
+	if t := nodeText(n, source, false); t != "" {
+		s.body = append(s.body, t)
+	}
+}
+
+// Values of notesOptions.Fallback
+const (
+	notesFallbackError = "error"
+	notesFallbackBody  = "body"
+)
+
+// notesOptions controls how narration is extracted from a deck
+type notesOptions struct {
+	// Fallback decides what happens to slides without a comment:
+	// "error" (or "") fails, "body" narrates the heading and body text
+	Fallback string
+	// Format is the deck dialect: "marp", "slidev", "reveal", "quarto", "pptx" or "auto" (or "") to detect it
+	Format string
+	// SkipComments is a regular expression; matching comments are not narrated
+	SkipComments string
+	// Delimiter is the only slide break of a Marp deck: "---", "***", "___" or a
+	// comment such as "<!-- slide -->" ("" = any rule)
+	Delimiter string
+}
+
+func (o notesOptions) validate() error {
+	switch o.Fallback {
+	case "", notesFallbackError, notesFallbackBody:
+	default:
+		return fmt.Errorf("invalid notes fallback: %q. Use %s or %s", o.Fallback, notesFallbackError, notesFallbackBody)
+	}
+	if _, err := regexp.Compile(o.SkipComments); err != nil {
+		return fmt.Errorf("invalid skip-comments pattern: %v", err)
+	}
+	if err := validateDelimiter(o.Delimiter); err != nil {
+		return err
+	}
+	return validateDeckFormat(o.Format)
+}
+
+// extractNotesFromMarkdown extracts HTML comments from a Markdown file using goldmark AST
+// Each slide is separated by "---" (ThematicBreak) and comments are in <!-- --> format.
+// Slidev decks may also carry a YAML frontmatter block after each separator.
+// Returns an error if any slide is missing a comment, unless opts.Fallback narrates its body instead
+func extractNotesFromMarkdown(content []byte, opts notesOptions) ([]SlideNote, error) {
+	slides, _, err := parseDeck(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []SlideNote
+	for i, slide := range slides {
+		var directives slideDirectives
+		for _, d := range slide.directives {
+			if err := directives.parse(d); err != nil {
+				return nil, fmt.Errorf("slide %d: %w", i+1, err)
+			}
+		}
+
+		if directives.Silence > 0 {
+			if len(slide.comments) > 0 {
+				return nil, fmt.Errorf("slide %d: a silence slide cannot also have narration", i+1)
+			}
+			notes = append(notes, SlideNote{SlideNumber: i + 1, Title: slide.title, Directives: directives})
+			continue
+		}
+
+		if len(slide.comments) == 0 {
+			title := slide.title
+			if title == "" {
+				title = "(no title)"
+			}
+			if opts.Fallback != notesFallbackBody || len(slide.body) == 0 {
+				return nil, fmt.Errorf("slide %d (%s) has no comment. All slides must have a <!-- --> comment", i+1, title)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: slide %d (%s) has no comment; narrating its body text\n", i+1, title)
+			slide.comments = []string{strings.Join(slide.body, "\n")}
+		}
+
+		for _, comment := range slide.comments {
+			if _, err := splitPauses(comment); err != nil {
+				return nil, fmt.Errorf("slide %d: %w", i+1, err)
+			}
+		}
+
+		transcript := make([]string, len(slide.comments))
+		for j, comment := range slide.comments {
+			transcript[j] = rubyBase(comment)
+		}
+		note := SlideNote{
+			SlideNumber: i + 1,
+			Title:       slide.title,
+			Note:        strings.Join(transcript, "\n"),
+			Directives:  directives,
+			spoken:      slide.comments,
+		}
+		if len(slide.comments) > 1 {
+			note.Segments = transcript
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+// splitNodesByThematicBreak splits AST nodes by ThematicBreak into slides
+// and extracts title and comments for each slide.
+// With headingLevel > 0, headings up to that level also start a new slide (Quarto).
+func splitNodesByThematicBreak(doc ast.Node, source []byte, headingLevel int) []slideInfo {
+	var slides []slideInfo
+	current := slideInfo{}
+	hasContent := false
+
+	for child := doc.FirstChild(); child != nil; child = child.NextSibling() {
+		if current.first == nil && child.Kind() != ast.KindThematicBreak {
+			current.first = child
+		}
+		switch n := child.(type) {
+		case *ast.ThematicBreak:
+			// Save current slide and start new one
+			if hasContent || current.title != "" || len(current.comments) > 0 {
+				slides = append(slides, current)
+			}
+			current = slideInfo{}
+			hasContent = false
+		case *ast.Heading:
+			if n.Level <= headingLevel && hasContent {
+				slides = append(slides, current)
+				current = slideInfo{first: child}
+			}
+			if n.Level <= 2 && current.title == "" {
+				current.title = extractHeadingText(n, source)
+			}
+			current.addBody(n, source)
+			hasContent = true
+		case *ast.HTMLBlock:
+			// Extract comment content from HTML block
+			comment := extractHTMLComment(n, source)
+			if isDirectiveComment(comment) {
+				current.directives = append(current.directives, comment)
+			} else if comment != "" {
+				current.comments = append(current.comments, comment)
+			}
+			hasContent = true
+		default:
+			current.addBody(n, source)
+			hasContent = true
+		}
+	}
+
+	// Add last slide
+	if hasContent || current.title != "" || len(current.comments) > 0 {
+		slides = append(slides, current)
+	}
+
+	return slides
+}
+
+// extractHeadingText extracts text content from a heading node
+func extractHeadingText(heading *ast.Heading, source []byte) string {
+	return string(heading.Text(source))
+}
+
+// extractHTMLComment extracts comment content from an HTML block
+// Returns empty string if not a comment
+func extractHTMLComment(block *ast.HTMLBlock, source []byte) string {
+	// Only process comment blocks (HTMLBlockType2)
+	if block.HTMLBlockType != ast.HTMLBlockType2 {
+		return ""
+	}
+
+	// Get the raw HTML content
+	var buf bytes.Buffer
+	for i := 0; i < block.Lines().Len(); i++ {
+		line := block.Lines().At(i)
+		buf.Write(line.Value(source))
+	}
+	// Multi-line comments keep their closing "-->" line separately
+	if block.HasClosure() {
+		buf.Write(block.ClosureLine.Value(source))
+	}
+	html := buf.String()
+
+	// Extract content between <!-- and -->
+	trimmed := strings.TrimSpace(html)
+	if !(strings.HasPrefix(trimmed, "<!--") && strings.HasSuffix(trimmed, "-->")) {
+		return ""
+	}
+	return strings.TrimSpace(trimmed[4 : len(trimmed)-3])
+}
+
+// ttsOptions controls a TTS generation run
+type ttsOptions struct {
+	Language  string
+	UseGemini bool
+	// AssumeYes skips confirmation prompts for destructive or costly operations
+	AssumeYes bool
+	// Retry applies to every provider request
+	Retry retryPolicy
+	// Proxy overrides HTTP(S)_PROXY for all provider requests
+	Proxy string
+	// Timeout bounds a single synthesis request for every provider (0 = 30s)
+	Timeout time.Duration
+	// SlideTimeout bounds all attempts for one slide (0 = no limit)
+	SlideTimeout time.Duration
+	// BreakerThreshold trips the local TTS circuit breaker after this many consecutive failures (0 = off)
+	BreakerThreshold int
+	// Fallback names the provider used once the breaker trips ("" = abort)
+	Fallback string
+	// Hooks receives per-slide progress callbacks (nil = none), and stage callbacks in RunPipeline
+	Hooks ProgressHooks
+	// MaxChars aborts runs whose notes exceed this many characters (0 = no limit)
+	MaxChars        int
+	AllowOverBudget bool
+	// Voice overrides the provider's default voice
+	Voice string
+	// OutputPattern names slide files (default: the padded file number + ".wav"); a
+	// text/template pattern is rendered per slide into slideNames
+	OutputPattern string
+	slideNames    map[int]string
+	// log orders the output of parallel slides; logSlide is the slide this copy belongs to
+	log      *slideLog
+	logSlide int
+	// NumberPadding zero-pads slide numbers in the default and template names (0 = 3 digits)
+	NumberPadding int
+	// NumberOffset shifts slide numbers in file names, e.g. -1 to start at 000
+	NumberOffset int
+	// Meta tags the WAV files with the deck's title, author and date
+	Meta deckMeta
+	// MaxChunkChars caps the text of one synthesis request (0 = provider default)
+	MaxChunkChars int
+	// AudioFormat is the file format of the slide audio: "wav" (or ""), "mp3" or "flac" (via ffmpeg)
+	AudioFormat string
+	// Bitrate applies to compressed formats (default defaultBitrate)
+	Bitrate string
+	// Crossfade overlaps consecutive slides in the --deck-audio track
+	Crossfade time.Duration
+	// Subtitles writes the notes as captions timed to the audio in this format ("" = none)
+	Subtitles string
+	// Align times the captions by transcribing the narration word by word (zero value = off)
+	Align transcriber
+	// Intro and Outro are clips put before and after the --deck-audio narration
+	Intro, Outro string
+	// BGM loops background music under the --deck-audio track
+	BGM bgmOptions
+	// Review plays each synthesized slide and asks to accept, regenerate or re-word it
+	Review bool
+	// Takes synthesizes every slide this many times into 007.take1.wav etc. (0 or 1 = once)
+	Takes int
+	// Denoise reduces hiss in synthesized slides before their levels are set
+	Denoise denoiseOptions
+	// AudioFilters is an ffmpeg -af chain run on each synthesized slide after Denoise
+	AudioFilters string
+	// Quality switches MP3 to VBR at this LAME quality (0 best, 9 smallest; nil = Bitrate)
+	Quality *int
+	// Silence is appended to each slide (see defaultTrailingSilence)
+	Silence time.Duration
+	// LeadingSilence is inserted before each slide's narration
+	LeadingSilence time.Duration
+	// Loudness is the integrated loudness each slide is normalized to in LUFS (0 = off)
+	Loudness float64
+	// SampleRate converts every clip to this rate in Hz (0 = keep the provider's)
+	SampleRate int
+	// Channels converts every clip to mono (1) or stereo (2) (0 = keep the provider's)
+	Channels int
+	// Tempo speeds narration up or down after synthesis, keeping the pitch (0 or 1 = unchanged)
+	Tempo float64
+	// Fade ramps the start and end of each slide's narration to remove clicks at concat points
+	Fade time.Duration
+	// DeckAudio also writes all slides as one track, audio-<lang>.<format>
+	DeckAudio bool
+	// QA transcribes the generated narration and compares it with the notes (zero value = off)
+	QA qaOptions
+	// Concurrency is the number of slides synthesized at once (0 = defaultTTSConcurrency)
+	Concurrency int
+	// Cache reuses provider audio of unchanged text from earlier runs (zero value = off)
+	Cache ttsCache
+	// OverridesDir holds hand recordings that replace synthesis of their slides ("" = none)
+	OverridesDir string
+	// Chapters marks each slide as a chapter of the deck audio (mp3 or m4a)
+	Chapters bool
+	// Gain is applied to each slide in dB after loudness normalization (0 = unchanged)
+	Gain float64
+	// NormalizePeak scales each slide so its peak sits at this level in dBFS (0 = off)
+	NormalizePeak float64
+	// Style is a delivery direction prepended to Gemini prompts (see tonePresets)
+	Style string
+	// Speed is the KokoVox speaking rate (0 = server default)
+	Speed float64
+	// SegmentPause separates the comments of a slide with several
+	SegmentPause time.Duration
+	// Lexicon rewrites terms to readings before synthesis (nil = none)
+	Lexicon *lexicon
+	// Acronyms spells out acronyms after the lexicon is applied (nil = none)
+	Acronyms *lexicon
+	// Normalize expands numbers, dates and units into words (see normalizeText)
+	Normalize bool
+	// URLPolicy decides how URLs in notes are read (keep, skip, domain, refer; "" = keep)
+	URLPolicy string
+	// EmojiPolicy decides how emoji are read (keep, strip, name; "" = keep)
+	EmojiPolicy string
+	// StripMarkdown reads markdown in notes as plain text (link labels, no emphasis markers)
+	StripMarkdown bool
+	// Notes controls how narration is extracted from the deck
+	Notes notesOptions
+	// SlideBudget warns about slides whose notes take longer to read (0 = off)
+	SlideBudget time.Duration
+	// SpeechRates are characters per second by primary language for SlideBudget (nil = defaults)
+	SpeechRates map[string]float64
+}
+
+// runTTSGeneration handles TTS generation from markdown file
+func runTTSGeneration(ctx context.Context, mdFile string, outputDir string, opts ttsOptions) error {
+	var keyManager *APIKeyManager
+	var err error
+
+	hooks := hooksOrNoop(opts.Hooks)
+
+	if opts.UseGemini || opts.Fallback == "gemini" {
+		// Initialize API key manager only when using Gemini (directly or as fallback)
+		keyManager, err = NewAPIKeyManager()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Read markdown file
+	content, err := os.ReadFile(mdFile)
+	if err != nil {
+		return fmt.Errorf("failed to read markdown file: %v", err)
+	}
+
+	// Create output directory
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	// Extract notes from markdown
+	notes, err := extractNotesFromMarkdown(content, opts.Notes)
+	if err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		return fmt.Errorf("no notes found in markdown file. Ensure comments are in <!-- --> format")
+	}
+
+	fmt.Printf("Found %d slides with notes\n", len(notes))
+	if opts.slideNames, err = slideNames(notes, opts); err != nil {
+		return err
+	}
+	rates := opts.SpeechRates
+	if rates == nil {
+		rates = defaultSpeechRates
+	}
+	for _, w := range lintNotes(notes, opts.Language, opts.SlideBudget, rates, opts.SegmentPause) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	// Slides with a hand recording in the overrides directory are not synthesized
+	recordings := findOverrides(opts.OverridesDir, notes, opts)
+	if len(recordings) > 0 {
+		fmt.Printf("%d slides use recordings from %s\n", len(recordings), opts.OverridesDir)
+	}
+
+	// Slides with identical narration are synthesized once and copied afterwards
+	unique, reuse := dedupeNotes(withoutSlides(notes, recordings))
+	if len(reuse) > 0 {
+		fmt.Printf("%d slides repeat earlier narration and will reuse its audio\n", len(reuse))
+	}
+
+	est := estimateRun(unique)
+	est.Chars *= max(opts.Takes, 1)
+	if err := checkBudget(est, opts); err != nil {
+		return err
+	}
+	if err := confirmRun(notes, est, outputDir, opts); err != nil {
+		return err
+	}
+
+	for _, note := range notes {
+		recording, ok := recordings[note.SlideNumber]
+		if !ok {
+			continue
+		}
+		outputPath := slideOutputPath(outputDir, note.SlideNumber, opts)
+		hooks.OnSlideStart(note.SlideNumber, len(note.Note))
+		err := useRecording(recording, note, outputPath, note.Directives.applyTo(opts))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to use the recording for slide %03d: %v\n", note.SlideNumber, err)
+		}
+		hooks.OnSlideDone(note.SlideNumber, outputPath, err)
+	}
+
+	// Trip the breaker, then abort the remaining slides unless a fallback provider takes over
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	breaker := newCircuitBreaker(opts.BreakerThreshold)
+
+	// Process notes concurrently (up to opts.Concurrency at a time); each slide's log lines
+	// are printed in slide order
+	sem := make(chan struct{}, cmp.Or(opts.Concurrency, defaultTTSConcurrency))
+	var wg sync.WaitGroup
+	log := newSlideLog(unique)
+
+	for _, note := range unique {
+		note := note // capture
+		outputPath := slideOutputPath(outputDir, note.SlideNumber, opts)
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer log.finish(note.SlideNumber)
+			opts := opts
+			opts.log, opts.logSlide = log, note.SlideNumber
+			opts.Retry.logf = opts.logf
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			opts.logf("[TTS] Processing slide %03d (length: %d chars)\n", note.SlideNumber, len(note.Note))
+			hooks.OnSlideStart(note.SlideNumber, len(note.Note))
+
+			// The slide deadline covers every retry of this slide
+			slideCtx, slideCancel := withOptionalTimeout(ctx, opts.SlideTimeout, fmt.Sprintf("slide %03d", note.SlideNumber))
+			defer slideCancel()
+			slideOpts := note.Directives.applyTo(opts)
+
+			if note.Directives.Silence > 0 {
+				err := writeWAVFile(outputPath, nil, cmp.Or(opts.Channels, 1), cmp.Or(opts.SampleRate, geminiSampleRate), 16, note.Directives.Silence, opts.Meta.wavMetadata(note))
+				if err == nil {
+					opts.logf("✓ Saved slide %03d: %s (%s of silence)\n", note.SlideNumber, outputPath, note.Directives.Silence)
+				} else {
+					opts.warnf("Warning: failed to write silence for slide %03d: %v\n", note.SlideNumber, err)
+				}
+				hooks.OnSlideDone(note.SlideNumber, outputPath, err)
+				return
+			}
+
+			fallback := breaker.Open() && opts.Fallback == "gemini"
+			if !opts.UseGemini && !fallback && ctx.Err() != nil {
+				// The breaker tripped and cancelled the run while this slide was starting
+				return
+			}
+			if opts.UseGemini || fallback {
+				err := synthesizeTakes(slideCtx, keyManager, note, outputPath, true, slideOpts)
+				err = annotateTimeout(slideCtx, err)
+				if err != nil {
+					opts.warnf("Warning: failed to generate TTS for slide %03d: %v\n", note.SlideNumber, redactErr(err))
+				}
+				hooks.OnSlideDone(note.SlideNumber, outputPath, err)
+				return
+			}
+
+			err := synthesizeTakes(slideCtx, keyManager, note, outputPath, false, slideOpts)
+			err = annotateTimeout(slideCtx, err)
+			hooks.OnSlideDone(note.SlideNumber, outputPath, err)
+			if err != nil && ctx.Err() == nil {
+				opts.warnf("Warning: failed to generate TTS for slide %03d: %v\n", note.SlideNumber, redactErr(err))
+			}
+			if breaker.Record(err) {
+				if opts.Fallback == "gemini" {
+					opts.warnf("Error: %v; switching remaining slides to Gemini\n", breaker.Err())
+				} else {
+					opts.warnf("Error: %v; aborting remaining slides\n", breaker.Err())
+					cancel()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if err := parent.Err(); err != nil {
+		return fmt.Errorf("run interrupted: %w", err)
+	}
+	if breaker.Open() && opts.Fallback == "" {
+		return fmt.Errorf("aborted: %v. Check the KokoVox service, or use --fallback gemini", breaker.Err())
+	}
+
+	if opts.Review {
+		if err := reviewSlides(ctx, keyManager, unique, outputDir, opts); err != nil {
+			return fmt.Errorf("review: %v", err)
+		}
+	}
+
+	if warnings := checkAudioQuality(outputDir, withoutSlides(notes, reuse), opts); len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "Audio quality check found %d problems; listen to these slides before assembling the video\n", len(warnings))
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+	}
+
+	if opts.QA.Backend != "" {
+		if warnings := checkNarration(ctx, withoutSlides(notes, reuse), outputDir, opts); len(warnings) > 0 {
+			fmt.Fprintf(os.Stderr, "Narration QA flagged %d slides; the TTS may have misread names or skipped sentences\n", len(warnings))
+			for _, w := range warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+			}
+		}
+	}
+
+	reuseDuplicateAudio(outputDir, notes, reuse, opts, hooks)
+	// The deck track is joined from the WAV files, which encoding replaces
+	var deckErr error
+	var deckOffset time.Duration
+	if opts.DeckAudio {
+		deckOffset, deckErr = writeDeckAudio(ctx, notes, outputDir, opts)
+	}
+	timings, err := measureTimings(notes, outputDir, deckOffset, opts)
+	if err != nil {
+		return err
+	}
+	var words map[int][]timedWord
+	if opts.Subtitles != "" && opts.Align.Backend != "" {
+		words = alignNarration(ctx, notes, outputDir, opts)
+	}
+	if err := encodeSlides(ctx, notes, outputDir, opts); err != nil {
+		return err
+	}
+	if err := writeTimings(outputDir, timings, opts); err != nil {
+		return err
+	}
+	if opts.Subtitles != "" {
+		if err := writeSubtitles(notes, timings, words, outputDir, opts.Subtitles, opts); err != nil {
+			return err
+		}
+	}
+	if deckErr != nil {
+		return deckErr
+	}
+
+	fmt.Println("TTS generation complete!")
+	return nil
+}
+
+// confirmRun asks for consent before replacing the takes of earlier runs, which may hold
+// the reads chosen in review, or starting a costly run. Plain slide files are overwritten.
+func confirmRun(notes []SlideNote, est runEstimate, outputDir string, opts ttsOptions) error {
+	withTakes := 0
+	for _, note := range notes {
+		if hasTakes(slideOutputPath(outputDir, note.SlideNumber, opts)) {
+			withTakes++
+		}
+	}
+
+	if withTakes > 0 {
+		ok, err := confirm(fmt.Sprintf("Overwrite the takes of %d slide(s) in %s?", withTakes, outputDir), opts.AssumeYes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted: existing takes were not overwritten")
+		}
+	}
+
+	if opts.UseGemini && est.Chars > defaultConfirmCharThreshold {
+		ok, err := confirm(fmt.Sprintf("This run will send %d characters to Gemini. Continue?", est.Chars), opts.AssumeYes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted by user")
+		}
+	}
+
+	return nil
+}
+
+// defaultTrailingSilence is the silence after each slide unless a tone, the deck or
+// --trailing-silence sets one: 1s for Gemini, none for KokoVox
+func defaultTrailingSilence(useGemini bool) time.Duration {
+	if useGemini {
+		return time.Second
+	}
+	return 0
+}
+
+// parseTrailingSilence reads --trailing-silence ("" = not set)
+func parseTrailingSilence(s string) (time.Duration, bool, error) {
+	if s == "" {
+		return 0, false, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, false, fmt.Errorf("invalid trailing silence %q: use a duration such as 0.5s (0s for none)", s)
+	}
+	return d, true, nil
+}
+
+// geminiSampleRate is the rate of the 16-bit mono PCM Gemini TTS returns
+const geminiSampleRate = 24000
+
+// processesAudio reports whether synthesized audio is changed before it is written,
+// so provider WAV files cannot be saved as-is
+func (o ttsOptions) processesAudio() bool {
+	return o.Silence != 0 || o.LeadingSilence != 0 || o.SampleRate != 0 || o.Channels != 0 ||
+		(o.Tempo != 0 && o.Tempo != 1) || o.Loudness != 0 || o.Gain != 0 || o.NormalizePeak != 0 || o.Fade != 0 ||
+		o.Meta.tagsSlides() || o.slideFilters() != ""
+}
+
+// synthesizeSlide generates every segment of a slide with one provider and writes the slide's WAV file.
+// Segments are separated by opts.SegmentPause and pause markers within them become silence.
+func synthesizeSlide(ctx context.Context, keyManager *APIKeyManager, note SlideNote, outputPath string, useGemini bool, opts ttsOptions) error {
+	var plan []speechPart
+	for i, segment := range note.speechSegments() {
+		if i > 0 {
+			plan = append(plan, speechPart{pause: opts.SegmentPause})
+		}
+		parts, err := splitPauses(segment)
+		if err != nil {
+			return err
+		}
+		// Long narration is sent in sentence-aligned chunks that stay within provider limits
+		for _, part := range parts {
+			if part.text == "" {
+				plan = append(plan, part)
+				continue
+			}
+			for _, chunk := range chunkText(speechText(part.text, opts), chunkLimit(opts, useGemini)) {
+				plan = append(plan, speechPart{text: chunk})
+			}
+		}
+	}
+
+	var pieces int
+	for _, part := range plan {
+		if part.text != "" {
+			pieces++
+		}
+	}
+	if pieces == 0 {
+		return fmt.Errorf("slide %03d has only pauses and no narration", note.SlideNumber)
+	}
+
+	source := "local TTS"
+	parts := make([]audioPart, 0, len(plan))
+	piece := 0
+	for _, part := range plan {
+		if part.text == "" {
+			parts = append(parts, audioPart{pause: part.pause})
+			continue
+		}
+		piece++
+		if pieces > 1 {
+			opts.logf("  Slide %03d part %d/%d\n", note.SlideNumber, piece, pieces)
+		}
+		text := part.text
+
+		key := cacheKey(useGemini, text, opts)
+
+		if useGemini {
+			clip, cached := opts.Cache.loadClip(key)
+			if cached {
+				source = "cache"
+			} else {
+				var keyIndex int
+				var err error
+				clip, keyIndex, err = generateGeminiTTS(ctx, keyManager, text, note.SlideNumber, opts)
+				if err != nil {
+					return err
+				}
+				if len(clip.Data) == 0 {
+					return fmt.Errorf("gemini returned no audio samples")
+				}
+				opts.Cache.storeClip(key, clip)
+				source = fmt.Sprintf("API key #%d", keyIndex)
+			}
+			parts = append(parts, audioPart{clip: convertChannels(resample(clip, opts.SampleRate), opts.Channels)})
+			continue
+		}
+
+		src, cached := opts.Cache.lookup(key)
+		if cached {
+			source = "cache"
+		} else {
+			tmp, err := generateLocalTTSFile(ctx, text, note.SlideNumber, filepath.Dir(outputPath), opts)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp)
+			if err := checkProviderWAV(tmp); err != nil {
+				return err
+			}
+			opts.Cache.storeFile(key, tmp)
+			src = tmp
+		}
+		// Local TTS returns WAV file directly, so plain narration without extra silence or tags is copied as-is
+		if len(plan) == 1 && !opts.processesAudio() {
+			if err := copyFile(src, outputPath); err != nil {
+				return fmt.Errorf("error saving WAV file: %v", err)
+			}
+			if err := verifySlideFile(outputPath, note, opts); err != nil {
+				return err
+			}
+			opts.logf("✓ Saved slide %03d: %s (using %s)\n", note.SlideNumber, outputPath, source)
+			return nil
+		}
+		clip, err := readSlideWAV(src)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, audioPart{clip: convertChannels(resample(clip, opts.SampleRate), opts.Channels)})
+	}
+
+	buf, err := joinAudio(parts)
+	if err != nil {
+		return err
+	}
+	if filters := opts.slideFilters(); filters != "" {
+		if buf, err = filterAudio(ctx, buf, filters); err != nil {
+			return err
+		}
+	}
+	buf = changeTempo(buf, opts.Tempo)
+	masterSlideAudio(buf, note, opts)
+	if err := writeWAVBuffer(outputPath, buf, opts.Meta.wavMetadata(note)); err != nil {
+		return fmt.Errorf("error saving WAV file: %v", err)
+	}
+
+	if err := verifySlideFile(outputPath, note, opts); err != nil {
+		return err
+	}
+
+	// Success!
+	opts.logf("✓ Saved slide %03d: %s (using %s)\n", note.SlideNumber, outputPath, source)
+	return nil
+}
+
+// masterSlideAudio applies the loudness, gain, fade and silence settings to a slide's narration
+func masterSlideAudio(buf *audio.IntBuffer, note SlideNote, opts ttsOptions) {
+	if opts.Loudness != 0 {
+		if reached, ok := normalizeLoudness(buf, opts.Loudness); ok && reached < opts.Loudness-0.5 {
+			opts.logf("  Slide %03d: normalized to %.1f LUFS instead of %g to avoid clipping\n", note.SlideNumber, reached, opts.Loudness)
+		}
+	}
+	if opts.Gain != 0 {
+		applyGain(buf, dbToGain(opts.Gain))
+	}
+	if opts.NormalizePeak != 0 {
+		normalizePeak(buf, opts.NormalizePeak)
+	}
+	applyFades(buf, opts.Fade, opts.Fade)
+	prependSilence(buf, opts.LeadingSilence)
+	appendSilence(buf, opts.Silence)
+}
+
+// generateGeminiTTS generates TTS using Gemini API and returns the audio with the index of the key that produced it
+func generateGeminiTTS(ctx context.Context, keyManager *APIKeyManager, text string, slideNum int, opts ttsOptions) (*audio.IntBuffer, int, error) {
+	// Every key gets at least one attempt so rotation still covers all keys
+	policy := opts.Retry
+	policy.MaxAttempts = max(policy.MaxAttempts, keyManager.KeyCount())
+
+	prompt := text
+	if opts.Style != "" {
+		prompt = opts.Style + ":\n" + text
+	}
+
+	var usedKey int
+	var clip *audio.IntBuffer
+	err := policy.do(ctx, func(attempt int) error {
+		// Get next API key (thread-safe)
+		apiKey, keyIndex := keyManager.NextKey()
+
+		opts.logf("  Slide %03d: attempting with API key #%d...\n", slideNum, keyIndex)
+
+		// Create client with current API key
+		client, err := genai.NewClient(ctx, &genai.ClientConfig{
+			APIKey:     apiKey,
+			HTTPClient: newHTTPClient(0, opts.Proxy),
+		})
+		if err != nil {
+			err = redactErr(err)
+			opts.logf("  Slide %03d: error creating client with API key #%d: %v\n", slideNum, keyIndex, err)
+			return err
+		}
+
+		config := &genai.GenerateContentConfig{
+			ResponseModalities: []string{"AUDIO"},
+			SpeechConfig: &genai.SpeechConfig{
+				VoiceConfig: &genai.VoiceConfig{
+					PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{
+						VoiceName: cmp.Or(opts.Voice, defaultGeminiVoice),
+					},
+				},
+			},
+		}
+
+		// Generate content with TTS (bounded by the per-request TTS timeout)
+		reqCtx, cancel := context.WithTimeout(ctx, cmp.Or(opts.Timeout, defaultTTSTimeout))
+		defer cancel()
+		result, err := client.Models.GenerateContent(reqCtx, geminiTTSModel, genai.Text(prompt), config)
+		if err != nil {
+			class := classifyError(err)
+			err = redactErr(err)
+			opts.logf("  Slide %03d: %s error with API key #%d: %v\n", slideNum, class, keyIndex, err)
+			// A rejected key is worth skipping when another key can take over
+			if class.retryable() || (class == errorAuth && keyManager.KeyCount() > 1) {
+				return err // Try next API key
+			}
+			return nonRetryable(fmt.Errorf("error generating TTS (%s): %w", class, err))
+		}
+
+		// Extract audio data
+		if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
+			opts.logf("  Slide %03d: no audio data found with API key #%d\n", slideNum, keyIndex)
+			return fmt.Errorf("no audio data found")
+		}
+
+		part := result.Candidates[0].Content.Parts[0]
+		if part.InlineData == nil || part.InlineData.Data == nil {
+			opts.logf("  Slide %03d: no inline data found with API key #%d\n", slideNum, keyIndex)
+			return fmt.Errorf("no inline data found")
+		}
+
+		clip = pcmToBuffer(part.InlineData.Data, 1, geminiSampleRate, 16)
+		usedKey = keyIndex
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed after retrying: %v", err)
+	}
+	return clip, usedKey, nil
+}
+
+// generateLocalTTSFile generates TTS using the local service, retrying per opts.Retry, and
+// streams the WAV response to a temporary file in dir, whose path it returns
+func generateLocalTTSFile(ctx context.Context, text string, slideNum int, dir string, opts ttsOptions) (string, error) {
+	f, err := os.CreateTemp(dir, ".tts-*.wav")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	err = opts.Retry.do(ctx, func(attempt int) error {
+		// Start over on every attempt, dropping what a failed response left behind
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nonRetryable(err)
+		}
+		if err := f.Truncate(0); err != nil {
+			return nonRetryable(err)
+		}
+		err := generateLocalTTS(ctx, text, opts, f)
+		if err != nil {
+			class := classifyError(err)
+			if !class.retryable() {
+				return nonRetryable(fmt.Errorf("%s error: %w", class, err))
+			}
+			opts.logf("  %s error from local TTS for slide %03d: %v\n", class, slideNum, redactErr(err))
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
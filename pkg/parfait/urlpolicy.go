@@ -0,0 +1,60 @@
+package parfait
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// URL reading policies: TTS engines otherwise spell URLs out character by character
+const (
+	urlPolicyKeep   = "keep"
+	urlPolicySkip   = "skip"
+	urlPolicyDomain = "domain"
+	urlPolicyRefer  = "refer"
+)
+
+var urlPolicies = []string{urlPolicyKeep, urlPolicySkip, urlPolicyDomain, urlPolicyRefer}
+
+var noteURLPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"'）」]+`)
+
+// urlReferPhrases replace URLs under the refer policy; other languages fall back to the domain
+var urlReferPhrases = map[string]string{
+	"en": "see the link on the slide",
+	"ja": "スライドのリンクを参照してください",
+}
+
+func validateURLPolicy(policy string) error {
+	if policy != "" && !slices.Contains(urlPolicies, policy) {
+		return fmt.Errorf("invalid URL policy: %q. Use %s", policy, strings.Join(urlPolicies, ", "))
+	}
+	return nil
+}
+
+// applyURLPolicy rewrites the URLs in text according to policy ("" = keep)
+func applyURLPolicy(text, policy, lang string) string {
+	if policy == "" || policy == urlPolicyKeep {
+		return text
+	}
+	return noteURLPattern.ReplaceAllStringFunc(text, func(raw string) string {
+		// Sentence punctuation right after a URL is not part of it
+		trimmed := strings.TrimRight(raw, ".,;:!?)]。、")
+		tail := raw[len(trimmed):]
+
+		switch policy {
+		case urlPolicySkip:
+			return tail
+		case urlPolicyRefer:
+			if phrase, ok := urlReferPhrases[baseLanguage(lang)]; ok {
+				return phrase + tail
+			}
+		}
+		u, err := url.Parse(trimmed)
+		if err != nil || u.Hostname() == "" {
+			return tail
+		}
+		return strings.TrimPrefix(u.Hostname(), "www.") + tail
+	})
+}
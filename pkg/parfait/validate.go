@@ -0,0 +1,79 @@
+package parfait
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Bounds of the slide length check, relative to the estimated reading time
+const (
+	maxLengthFactor = 4
+	maxLengthSlack  = 10 * time.Second
+	minLengthFactor = 0.25
+	// minCheckedLength skips the lower bound for short notes, whose estimate is unreliable
+	minCheckedLength = 2 * time.Second
+)
+
+// checkProviderWAV rejects a provider response saved at path that is not playable audio,
+// such as an error page returned with status 200, before it is used or cached
+func checkProviderWAV(path string) error {
+	info, err := readWAVInfo(path)
+	if err != nil {
+		head := make([]byte, 80)
+		if f, openErr := os.Open(path); openErr == nil {
+			n, _ := io.ReadFull(f, head)
+			head = head[:n]
+			f.Close()
+		}
+		return fmt.Errorf("provider returned a response that is not a WAV file: %q", responseSnippet(head))
+	}
+	if info.Duration() == 0 {
+		return fmt.Errorf("provider returned a WAV file without audio")
+	}
+	return nil
+}
+
+// responseSnippet is the start of a response body for error messages
+func responseSnippet(data []byte) string {
+	const limit = 80
+	s := string(data[:min(len(data), limit)])
+	if !utf8.ValidString(s) {
+		return fmt.Sprintf("% x", data[:min(len(data), 16)])
+	}
+	return redact(strings.TrimSpace(s))
+}
+
+// verifySlideFile re-reads the header of a written slide. An unreadable or empty file is an
+// error; narration far longer or shorter than the estimated reading time is a warning,
+// since it usually means the provider read something else or cut off.
+func verifySlideFile(path string, note SlideNote, opts ttsOptions) error {
+	info, err := readWAVInfo(path)
+	if err != nil {
+		return fmt.Errorf("written audio is unreadable: %v", err)
+	}
+	length := info.Duration()
+	if length == 0 {
+		return fmt.Errorf("written audio %s is empty", path)
+	}
+
+	rates := opts.SpeechRates
+	if rates == nil {
+		rates = defaultSpeechRates
+	}
+	expected := estimateSpeech(note, opts.Language, rates, opts.SegmentPause)
+	if opts.Tempo > 0 {
+		expected = time.Duration(float64(expected) / opts.Tempo)
+	}
+	narration := length - opts.Silence - opts.LeadingSilence
+	switch {
+	case narration > maxLengthFactor*expected+maxLengthSlack:
+		opts.warnf("Warning: slide %03d is %s long, far more than the estimated %s\n", note.SlideNumber, narration.Round(time.Second/10), expected.Round(time.Second/10))
+	case expected >= minCheckedLength && narration < time.Duration(minLengthFactor*float64(expected)):
+		opts.warnf("Warning: slide %03d is %s long, far less than the estimated %s; the narration may be cut off\n", note.SlideNumber, narration.Round(time.Second/10), expected.Round(time.Second/10))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package parfait
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// wavInfo is the stream format and length of a WAV file, read from its header
+type wavInfo struct {
+	Format     int
+	Channels   int
+	SampleRate int
+	BitDepth   int
+	DataBytes  int64
+}
+
+// Duration is the playing time of the data chunk
+func (w wavInfo) Duration() time.Duration {
+	frameBytes := int64(w.Channels * w.BitDepth / 8)
+	if frameBytes == 0 || w.SampleRate == 0 {
+		return 0
+	}
+	frames := w.DataBytes / frameBytes
+	return time.Duration(frames) * time.Second / time.Duration(w.SampleRate)
+}
+
+// readWAVInfo reads the header of a WAV file (see parseWAVInfo)
+func readWAVInfo(path string) (wavInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return wavInfo{}, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return wavInfo{}, err
+	}
+	info, err := parseWAVInfo(f, stat.Size())
+	if err != nil {
+		return wavInfo{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return info, nil
+}
+
+// parseWAVInfo walks the RIFF chunks of a WAV stream of the given size up to the data
+// chunk without reading the samples. A data size beyond the end of the stream (streamed
+// WAV files write a placeholder) is cut to what is actually there.
+func parseWAVInfo(r io.ReadSeeker, size int64) (wavInfo, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return wavInfo{}, fmt.Errorf("not a WAV file")
+	}
+
+	var info wavInfo
+	offset := int64(12)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return wavInfo{}, fmt.Errorf("no data chunk")
+		}
+		offset += 8
+		chunkSize := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			var fmtChunk [16]byte
+			if chunkSize < 16 {
+				return wavInfo{}, fmt.Errorf("malformed fmt chunk")
+			}
+			if _, err := io.ReadFull(r, fmtChunk[:]); err != nil {
+				return wavInfo{}, err
+			}
+			info.Format = int(binary.LittleEndian.Uint16(fmtChunk[0:2]))
+			info.Channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			info.BitDepth = int(binary.LittleEndian.Uint16(fmtChunk[14:16]))
+		case "data":
+			if info.Channels == 0 {
+				return wavInfo{}, fmt.Errorf("no fmt chunk before the data")
+			}
+			info.DataBytes = min(chunkSize, size-offset)
+			return info, nil
+		}
+		// Chunks are padded to an even size
+		offset += chunkSize + chunkSize%2
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return wavInfo{}, err
+		}
+	}
+}